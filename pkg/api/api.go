@@ -1,16 +1,27 @@
 package api
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strings"
+	texttemplate "text/template"
 
 	"github.com/argoproj/notifications-engine/pkg/services"
 	"github.com/argoproj/notifications-engine/pkg/templates"
 	"github.com/argoproj/notifications-engine/pkg/triggers"
+	"github.com/argoproj/notifications-engine/pkg/util/misc"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 const (
 	serviceTypeVarName = "serviceType"
 	recipientVarName   = "recipient"
+	localeVarName      = templates.LocaleVarName
+	varsVarName        = "vars"
+	severityVarName    = "severity"
 )
 
 //go:generate mockgen -destination=../mocks/api.go -package=mocks github.com/argoproj/notifications-engine/pkg/api API
@@ -20,10 +31,15 @@ type GetVars func(obj map[string]interface{}, dest services.Destination) map[str
 // API provides high level interface to send notifications and manage notification services
 type API interface {
 	Send(obj map[string]interface{}, templates []string, dest services.Destination) error
+	// Render renders the notification for the destination without sending it
+	Render(obj map[string]interface{}, templates []string, dest services.Destination) (*services.Notification, error)
 	RunTrigger(triggerName string, vars map[string]interface{}) ([]triggers.ConditionResult, error)
 	AddNotificationService(name string, service services.NotificationService)
 	GetNotificationServices() map[string]services.NotificationService
 	GetConfig() Config
+	// GetGlobalDestinations returns destinations of the default subscriptions that match the object and its namespace
+	// labels, templated recipients are rendered using the object variables
+	GetGlobalDestinations(obj map[string]interface{}, namespaceLabels map[string]string) services.Destinations
 }
 
 type api struct {
@@ -32,6 +48,7 @@ type api struct {
 	triggersService      triggers.Service
 	getVars              GetVars
 	config               Config
+	recipientTemplates   map[string]*texttemplate.Template
 }
 
 func (n *api) GetConfig() Config {
@@ -48,6 +65,29 @@ func (n *api) GetNotificationServices() map[string]services.NotificationService
 	return n.notificationServices
 }
 
+func (n *api) GetGlobalDestinations(obj map[string]interface{}, namespaceLabels map[string]string) services.Destinations {
+	var vars map[string]interface{}
+	labels := (&unstructured.Unstructured{Object: obj}).GetLabels()
+	return n.config.getGlobalDestinations(labels, namespaceLabels, func(recipient string) (string, bool) {
+		tmpl, ok := n.recipientTemplates[recipient]
+		if !ok {
+			return recipient, true
+		}
+		if vars == nil {
+			vars = obj
+			if n.getVars != nil {
+				vars = n.getVars(obj, services.Destination{})
+			}
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, vars); err != nil {
+			log.Warnf("Failed to render recipient '%s': %v", recipient, err)
+			return "", false
+		}
+		return buf.String(), buf.Len() > 0
+	})
+}
+
 // Send sends notification using specified service and template to the specified destination
 func (n *api) Send(obj map[string]interface{}, templates []string, dest services.Destination) error {
 	notificationService, ok := n.notificationServices[dest.Service]
@@ -55,6 +95,16 @@ func (n *api) Send(obj map[string]interface{}, templates []string, dest services
 		return fmt.Errorf("notification service '%s' is not supported", dest.Service)
 	}
 
+	notification, err := n.Render(obj, templates, dest)
+	if err != nil {
+		return err
+	}
+
+	return notificationService.Send(*notification, dest)
+}
+
+// Render renders notification using specified templates for the specified destination
+func (n *api) Render(obj map[string]interface{}, templates []string, dest services.Destination) (*services.Notification, error) {
 	vars := n.getVars(obj, dest)
 
 	in := make(map[string]interface{})
@@ -63,16 +113,23 @@ func (n *api) Send(obj map[string]interface{}, templates []string, dest services
 	}
 	in[serviceTypeVarName] = dest.Service
 	in[recipientVarName] = dest.Recipient
-	notification, err := n.templatesService.FormatNotification(in, templates...)
-	if err != nil {
-		return err
+	in[localeVarName] = dest.Locale
+	if dest.Options != nil {
+		if dest.Options.Vars != nil {
+			in[varsVarName] = dest.Options.Vars
+		}
+		if dest.Options.Severity != "" {
+			in[severityVarName] = dest.Options.Severity
+		}
 	}
-
-	return notificationService.Send(*notification, dest)
+	return n.templatesService.FormatNotification(in, templates...)
 }
 
 func (n *api) RunTrigger(triggerName string, obj map[string]interface{}) ([]triggers.ConditionResult, error) {
-	vars := n.getVars(obj, services.Destination{})
+	vars := obj
+	if n.getVars != nil {
+		vars = n.getVars(obj, services.Destination{})
+	}
 	return n.triggersService.Run(triggerName, vars)
 }
 
@@ -86,14 +143,83 @@ func NewAPI(cfg Config, getVars GetVars) (*api, error) {
 		}
 		notificationServices[k] = svc
 	}
-	triggersService, err := triggers.NewService(cfg.Triggers)
+	triggerOpts := []triggers.Opts{
+		triggers.WithFuncs(cfg.ExprFuncs),
+		triggers.WithFragments(cfg.Fragments),
+		triggers.WithModes(cfg.TriggerModes),
+		triggers.WithStrictMode(cfg.StrictTriggers),
+	}
+	if getVars != nil {
+		triggerOpts = append(triggerOpts, triggers.WithSampleVars(getVars(map[string]interface{}{}, services.Destination{})))
+	}
+	triggersService, err := triggers.NewService(cfg.Triggers, triggerOpts...)
 	if err != nil {
 		return nil, err
 	}
-	templatesService, err := templates.NewService(cfg.Templates)
+	templatesService, err := templates.NewService(cfg.Templates, templates.WithPartials(cfg.Partials), templates.WithFuncs(cfg.TemplateFuncs), templates.WithLimits(cfg.TemplateLimits))
 	if err != nil {
 		return nil, err
 	}
 
-	return &api{notificationServices, templatesService, triggersService, getVars, cfg}, nil
+	recipientTemplates := map[string]*texttemplate.Template{}
+	funcs := templates.Funcs()
+	for name, fn := range cfg.TemplateFuncs {
+		funcs[name] = fn
+	}
+	for _, s := range cfg.Subscriptions {
+		for _, recipient := range s.Recipients {
+			if !isTemplatedRecipient(recipient) {
+				continue
+			}
+			tmpl, err := texttemplate.New(recipient).Funcs(funcs).Option("missingkey=error").Parse(recipient)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse recipient '%s': %v", recipient, err)
+			}
+			recipientTemplates[recipient] = tmpl
+		}
+	}
+
+	return &api{notificationServices, templatesService, triggersService, getVars, cfg, recipientTemplates}, nil
+}
+
+// TriggerTestResult holds the result of the trigger test case
+type TriggerTestResult struct {
+	Trigger string
+	Test    string
+	// Error describes why the test case failed, nil if the test case passed
+	Error error
+}
+
+// RunTriggerTests executes the test cases of the triggers and returns results sorted by trigger name
+func RunTriggerTests(n API, tests map[string][]triggers.TestCase) []TriggerTestResult {
+	var res []TriggerTestResult
+	misc.IterateStringKeyMap(tests, func(name string) {
+		for _, test := range tests[name] {
+			results, err := n.RunTrigger(name, test.Object)
+			if err == nil {
+				err = test.Verify(results)
+			}
+			res = append(res, TriggerTestResult{Trigger: name, Test: test.Name, Error: err})
+		}
+	})
+	return res
+}
+
+// ValidateConfig creates the api using the given config and executes test cases of the triggers. The returned error
+// describes all failed test cases.
+func ValidateConfig(cfg Config, getVars GetVars) error {
+	n, err := NewAPI(cfg, getVars)
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, res := range RunTriggerTests(n, cfg.TriggerTests) {
+		if res.Error != nil {
+			errs = append(errs, fmt.Sprintf("trigger '%s': %v", res.Trigger, res.Error))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
 }
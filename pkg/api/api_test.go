@@ -1,13 +1,17 @@
 package api
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/argoproj/notifications-engine/pkg/services"
 	"github.com/argoproj/notifications-engine/pkg/services/mocks"
+	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+	"github.com/argoproj/notifications-engine/pkg/triggers"
 )
 
 func getVars(in map[string]interface{}, _ services.Destination) map[string]interface{} {
@@ -71,3 +75,121 @@ func TestAddService(t *testing.T) {
 	assert.NotNil(t, servicesMap["slack"])
 	assert.NotNil(t, servicesMap["hello"])
 }
+
+func TestSend_TemplateFuncs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := getConfig(ctrl, func(service *mocks.MockNotificationService) {
+		service.EXPECT().Send(services.Notification{
+			Message: "hello WORLD slack:my-channel",
+		}, services.Destination{
+			Service:   "slack",
+			Recipient: "my-channel",
+		}).Return(nil)
+	})
+	cfg.Templates["my-template"] = services.Notification{
+		Message: "hello {{ shout .foo }} {{ .serviceType }}:{{ .recipient }}",
+	}
+	cfg.TemplateFuncs = map[string]interface{}{"shout": strings.ToUpper}
+	api, err := NewAPI(cfg, getVars)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = api.Send(
+		map[string]interface{}{"foo": "world"},
+		[]string{"my-template"},
+		services.Destination{Service: "slack", Recipient: "my-channel"},
+	)
+	assert.NoError(t, err)
+}
+
+func TestSend_DestinationOptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dest := services.Destination{Service: "slack", Recipient: "my-channel", Options: &services.DestinationOptions{
+		Vars:     map[string]interface{}{"team": "platform"},
+		Severity: "critical",
+	}}
+	cfg := getConfig(ctrl, func(service *mocks.MockNotificationService) {
+		service.EXPECT().Send(services.Notification{Message: "critical platform"}, dest).Return(nil)
+	})
+	cfg.Templates["my-template"] = services.Notification{Message: "{{ .severity }} {{ .vars.team }}"}
+	api, err := NewAPI(cfg, getVars)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = api.Send(map[string]interface{}{"foo": "world"}, []string{"my-template"}, dest)
+	assert.NoError(t, err)
+}
+
+func TestGetGlobalDestinations_TemplatedRecipients(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := getConfig(ctrl)
+	cfg.Subscriptions = subscriptions.DefaultSubscriptions{{
+		Recipients: []string{"slack:{{ .metadata.labels.team }}-alerts", "slack:all-alerts"},
+		Triggers:   []string{"my-trigger"},
+		Selector:   labels.Everything(),
+	}}
+	api, err := NewAPI(cfg, getVars)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dests := api.GetGlobalDestinations(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "team-a"}},
+	}, nil)
+	assert.Equal(t, services.Destinations{"my-trigger": {
+		{Service: "slack", Recipient: "team-a-alerts"},
+		{Service: "slack", Recipient: "all-alerts"},
+	}}, dests)
+
+	dests = api.GetGlobalDestinations(map[string]interface{}{"metadata": map[string]interface{}{}}, nil)
+	assert.Equal(t, services.Destinations{"my-trigger": {{Service: "slack", Recipient: "all-alerts"}}}, dests)
+
+	assert.Equal(t, services.Destinations{"my-trigger": {{Service: "slack", Recipient: "all-alerts"}}},
+		cfg.GetGlobalDestinations(map[string]string{}))
+
+	api, err = NewAPI(cfg, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	dests = api.GetGlobalDestinations(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "team-b"}},
+	}, nil)
+	assert.Equal(t, services.Destinations{"my-trigger": {
+		{Service: "slack", Recipient: "team-b-alerts"},
+		{Service: "slack", Recipient: "all-alerts"},
+	}}, dests)
+}
+
+func TestValidateConfig(t *testing.T) {
+	cfg := Config{
+		Triggers: map[string][]triggers.Condition{
+			"my-trigger": {{When: "app.status == 'Degraded'", Send: []string{"my-template"}}},
+		},
+		TriggerTests: map[string][]triggers.TestCase{
+			"my-trigger": {{
+				Name:      "degraded",
+				Object:    map[string]interface{}{"status": "Degraded"},
+				Triggered: []string{"[0]"},
+			}, {
+				Name:   "healthy",
+				Object: map[string]interface{}{"status": "Healthy"},
+			}},
+		},
+	}
+	getAppVars := func(obj map[string]interface{}, _ services.Destination) map[string]interface{} {
+		return map[string]interface{}{"app": obj}
+	}
+	assert.NoError(t, ValidateConfig(cfg, getAppVars))
+
+	cfg.TriggerTests["my-trigger"][1].Triggered = []string{"[0]"}
+	assert.EqualError(t, ValidateConfig(cfg, getAppVars),
+		"trigger 'my-trigger': test case 'healthy' failed: expected triggered conditions [[0]], got []")
+}
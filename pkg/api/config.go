@@ -2,11 +2,12 @@ package api
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
+	texttemplate "text/template"
 
 	"github.com/argoproj/notifications-engine/pkg/services"
 	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+	"github.com/argoproj/notifications-engine/pkg/templates"
 	"github.com/argoproj/notifications-engine/pkg/triggers"
 
 	"github.com/ghodss/yaml"
@@ -16,6 +17,9 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 )
 
+// RecipientGroupService is the service name used to reference recipient groups, e.g. group:platform-team
+const RecipientGroupService = "group"
+
 type ServiceFactory func() (services.NotificationService, error)
 
 // Config holds settings required to create new api
@@ -23,16 +27,57 @@ type Config struct {
 	Services  map[string]ServiceFactory
 	Triggers  map[string][]triggers.Condition
 	Templates map[string]services.Notification
+	// ServiceTypes holds the type of each configured service keyed by the service name
+	ServiceTypes map[string]string
+	// TriggerModes holds evaluation modes of the triggers
+	TriggerModes map[string]triggers.Mode
+	// SuppressInitialSync holds triggers that do not send notifications about conditions that are true when the
+	// controller starts
+	SuppressInitialSync map[string]bool
+	// TriggerTests holds test cases of the triggers
+	TriggerTests map[string][]triggers.TestCase
+	// Fragments holds named expressions that can be referenced from trigger conditions
+	Fragments map[string]string
+	// Partials holds templates that can be included into any notification template
+	Partials map[string]string
+	// TemplateLimits holds the notification rendering limits
+	TemplateLimits templates.Limits
 	// Subscriptions holds list of default application subscriptions
 	Subscriptions subscriptions.DefaultSubscriptions
+	// RecipientGroups holds lists of recipients in the <service>:<recipient> format keyed by the group name
+	RecipientGroups map[string][]string
 	// DefaultTriggers holds list of triggers that is used by default if subscriber don't specify trigger
 	DefaultTriggers []string
 	// ServiceDefaultTriggers holds list of default triggers per service
 	ServiceDefaultTriggers map[string][]string
+	// TemplateFuncs holds additional functions available in notification templates
+	TemplateFuncs texttemplate.FuncMap
+	// ExprFuncs holds additional functions available in trigger expressions
+	ExprFuncs map[string]interface{}
+	// StrictTriggers enables strict evaluation of trigger expressions
+	StrictTriggers bool
 }
 
-// Returns list of destinations for the specified trigger
+// Returns list of destinations for the specified trigger. Templated recipients are skipped, use API.GetGlobalDestinations
+// to get destinations with rendered recipients.
 func (cfg Config) GetGlobalDestinations(labels map[string]string) services.Destinations {
+	return cfg.GetGlobalDestinationsInNamespace(labels, nil)
+}
+
+// GetGlobalDestinationsInNamespace returns list of destinations for the resource with the given labels in the namespace
+// with the given labels. Templated recipients are skipped.
+func (cfg Config) GetGlobalDestinationsInNamespace(labels map[string]string, namespaceLabels map[string]string) services.Destinations {
+	return cfg.getGlobalDestinations(labels, namespaceLabels, func(recipient string) (string, bool) {
+		return recipient, !isTemplatedRecipient(recipient)
+	})
+}
+
+// isTemplatedRecipient returns true if the recipient is a template that should be rendered for every resource
+func isTemplatedRecipient(recipient string) bool {
+	return strings.Contains(recipient, "{{")
+}
+
+func (cfg Config) getGlobalDestinations(labels map[string]string, namespaceLabels map[string]string, resolve func(recipient string) (string, bool)) services.Destinations {
 	dests := services.Destinations{}
 	for _, s := range cfg.Subscriptions {
 		triggers := s.Triggers
@@ -40,14 +85,13 @@ func (cfg Config) GetGlobalDestinations(labels map[string]string) services.Desti
 			triggers = cfg.DefaultTriggers
 		}
 		for _, trigger := range triggers {
-			if s.MatchesTrigger(trigger) && s.Selector.Matches(fields.Set(labels)) {
+			if s.MatchesTrigger(trigger) && s.Selector.Matches(fields.Set(labels)) && s.MatchesNamespace(namespaceLabels) {
 				for _, recipient := range s.Recipients {
-					parts := strings.Split(recipient, ":")
-					dest := services.Destination{Service: parts[0]}
-					if len(parts) > 1 {
-						dest.Recipient = parts[1]
+					recipient, ok := resolve(recipient)
+					if !ok {
+						continue
 					}
-					dests[trigger] = append(dests[trigger], dest)
+					dests[trigger] = append(dests[trigger], parseDestination(recipient, s.Locale))
 				}
 			}
 		}
@@ -55,27 +99,64 @@ func (cfg Config) GetGlobalDestinations(labels map[string]string) services.Desti
 	return dests
 }
 
-var keyPattern = regexp.MustCompile(`[$][\w-_]+`)
+// parseDestination parses the recipient in the <service>:<recipient> format, the recipient might contain colons
+func parseDestination(recipient string, locale string) services.Destination {
+	parts := strings.SplitN(recipient, ":", 2)
+	dest := services.Destination{Service: parts[0], Locale: locale}
+	if len(parts) > 1 {
+		dest.Recipient = parts[1]
+	}
+	return dest
+}
 
-// replaceStringSecret checks if given string is a secret key reference ( starts with $ ) and returns corresponding value from provided map
-func replaceStringSecret(val string, secretValues map[string][]byte) string {
-	return keyPattern.ReplaceAllStringFunc(val, func(secretKey string) string {
-		secretVal, ok := secretValues[secretKey[1:]]
-		if !ok {
-			log.Warnf("config referenced '%s', but key does not exist in secret", val)
-			return secretKey
+// ExpandRecipientGroups replaces destinations that reference recipient groups with the group members
+func (cfg Config) ExpandRecipientGroups(dests services.Destinations) services.Destinations {
+	res := services.Destinations{}
+	for trigger, items := range dests {
+		for _, dest := range items {
+			if dest.Service != RecipientGroupService {
+				res[trigger] = append(res[trigger], dest)
+				continue
+			}
+			members, ok := cfg.RecipientGroups[dest.Recipient]
+			if !ok {
+				log.Warnf("Recipient group '%s' is not configured", dest.Recipient)
+				continue
+			}
+			for _, member := range members {
+				memberDest := parseDestination(member, dest.Locale)
+				memberDest.Options = dest.Options
+				res[trigger] = append(res[trigger], memberDest)
+			}
 		}
-		return string(secretVal)
-	})
+	}
+	return res
 }
 
 // ParseConfig retrieves Config from given ConfigMap and Secret
 func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
+	return ParseConfigWithSecrets(configMap, secret, nil)
+}
+
+// ParseConfigWithSecrets retrieves Config from given ConfigMap and Secret. The getSecret function is used to resolve
+// references to other Secrets in service configuration.
+func ParseConfigWithSecrets(configMap *v1.ConfigMap, secret *v1.Secret, getSecret SecretGetter) (*Config, error) {
+	return parseConfig(configMap, &referenceResolver{secret: secret, getSecret: getSecret, strict: true})
+}
+
+func parseConfig(configMap *v1.ConfigMap, resolver *referenceResolver) (*Config, error) {
 	cfg := Config{
 		Services:               map[string]ServiceFactory{},
+		ServiceTypes:           map[string]string{},
 		Triggers:               map[string][]triggers.Condition{},
 		ServiceDefaultTriggers: map[string][]string{},
 		Templates:              map[string]services.Notification{},
+		Partials:               map[string]string{},
+		Fragments:              map[string]string{},
+		TriggerModes:           map[string]triggers.Mode{},
+		SuppressInitialSync:    map[string]bool{},
+		TriggerTests:           map[string][]triggers.TestCase{},
+		TemplateLimits:         templates.DefaultLimits,
 	}
 	if subscriptionYaml, ok := configMap.Data["subscriptions"]; ok {
 		if err := yaml.Unmarshal([]byte(subscriptionYaml), &cfg.Subscriptions); err != nil {
@@ -89,16 +170,43 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 		}
 	}
 
+	if recipientGroupsYaml, ok := configMap.Data["recipientGroups"]; ok {
+		if err := yaml.Unmarshal([]byte(recipientGroupsYaml), &cfg.RecipientGroups); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recipientGroups: %v", err)
+		}
+		for name, members := range cfg.RecipientGroups {
+			for _, member := range members {
+				if strings.HasPrefix(member, RecipientGroupService+":") {
+					return nil, fmt.Errorf("recipient group '%s' references another group '%s'", name, member)
+				}
+			}
+		}
+	}
+
+	if strictTriggersYaml, ok := configMap.Data["strictTriggers"]; ok {
+		if err := yaml.Unmarshal([]byte(strictTriggersYaml), &cfg.StrictTriggers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal strictTriggers: %v", err)
+		}
+	}
+
+	if limitsYaml, ok := configMap.Data["templateLimits"]; ok {
+		if err := yaml.Unmarshal([]byte(limitsYaml), &cfg.TemplateLimits); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal template limits: %v", err)
+		}
+	}
+
 	for k, v := range configMap.Data {
 		parts := strings.Split(k, ".")
 		switch {
 		case strings.HasPrefix(k, "template."):
 			name := strings.Join(parts[1:], ".")
-			template := services.Notification{}
-			if err := yaml.Unmarshal([]byte(v), &template); err != nil {
+			template, err := templates.UnmarshalNotification([]byte(v))
+			if err != nil {
 				return nil, fmt.Errorf("failed to unmarshal template %s: %v", name, err)
 			}
 			cfg.Templates[name] = template
+		case strings.HasPrefix(k, templates.PartialPrefix):
+			cfg.Partials[strings.TrimPrefix(k, templates.PartialPrefix)] = v
 		case strings.HasPrefix(k, "service."):
 			name := ""
 			serviceType := ""
@@ -111,7 +219,7 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 				return nil, fmt.Errorf("invalid service key; expected 'service.<type>(.<name>)' but got '%s'", k)
 			}
 
-			optsData, err := replaceServiceConfigSecrets(v, secret)
+			optsData, err := replaceServiceConfigSecrets(v, resolver)
 			if err != nil {
 				return nil, fmt.Errorf("failed to render service configuration %s: %v", serviceType, err)
 			}
@@ -119,13 +227,25 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 			cfg.Services[name] = func() (services.NotificationService, error) {
 				return services.NewService(serviceType, optsData)
 			}
+			cfg.ServiceTypes[name] = serviceType
 		case strings.HasPrefix(k, "trigger."):
 			name := strings.Join(parts[1:], ".")
-			var trigger []triggers.Condition
-			if err := yaml.Unmarshal([]byte(v), &trigger); err != nil {
+			trigger, err := unmarshalTrigger([]byte(v))
+			if err != nil {
 				return nil, fmt.Errorf("failed to unmarshal trigger %s: %v", name, err)
 			}
-			cfg.Triggers[name] = trigger
+			cfg.Triggers[name] = trigger.Conditions
+			if trigger.Mode != "" {
+				cfg.TriggerModes[name] = trigger.Mode
+			}
+			if trigger.SuppressInitialSync {
+				cfg.SuppressInitialSync[name] = true
+			}
+			if len(trigger.Tests) > 0 {
+				cfg.TriggerTests[name] = trigger.Tests
+			}
+		case strings.HasPrefix(k, "fragment."):
+			cfg.Fragments[strings.TrimPrefix(k, "fragment.")] = v
 		case strings.HasPrefix(k, "defaultTriggers."):
 			name := strings.Join(parts[1:], ".")
 			var defaultTriggers []string
@@ -138,18 +258,36 @@ func ParseConfig(configMap *v1.ConfigMap, secret *v1.Secret) (*Config, error) {
 	return &cfg, nil
 }
 
-func replaceServiceConfigSecrets(inputYaml string, secret *v1.Secret) ([]byte, error) {
+// unmarshalTrigger parses the trigger specified either as a list of conditions or as an object with conditions and mode
+func unmarshalTrigger(data []byte) (triggers.Trigger, error) {
+	var conditions []triggers.Condition
+	err := yaml.Unmarshal(data, &conditions)
+	if err == nil {
+		return triggers.Trigger{Conditions: conditions}, nil
+	}
+	var trigger triggers.Trigger
+	if yaml.Unmarshal(data, &trigger) != nil {
+		return trigger, err
+	}
+	return trigger, nil
+}
+
+func replaceServiceConfigSecrets(inputYaml string, resolver *referenceResolver) ([]byte, error) {
 	var node yaml3.Node
 	err := yaml3.Unmarshal([]byte(inputYaml), &node)
 	if err != nil {
 		return nil, err
 	}
 
+	var resolveErr error
 	walkYamlDocument(&node, func(visitedNode *yaml3.Node) {
-		if visitedNode.Kind == yaml3.ScalarNode && visitedNode.Tag == "!!str" {
-			visitedNode.Value = replaceStringSecret(visitedNode.Value, secret.Data)
+		if resolveErr == nil && visitedNode.Kind == yaml3.ScalarNode && visitedNode.Tag == "!!str" {
+			visitedNode.Value, resolveErr = resolver.resolve(visitedNode.Value)
 		}
 	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
 
 	if result, err := yaml3.Marshal(&node); err != nil {
 		return nil, err
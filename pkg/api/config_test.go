@@ -1,10 +1,16 @@
 package api
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/argoproj/notifications-engine/pkg/services"
 	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+	"github.com/argoproj/notifications-engine/pkg/templates"
+	"github.com/argoproj/notifications-engine/pkg/triggers"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -26,6 +32,7 @@ token: my-token
 	}
 
 	assert.NotNil(t, cfg.Services["slack"])
+	assert.Equal(t, map[string]string{"slack": "slack"}, cfg.ServiceTypes)
 }
 
 func TestParseConfig_Templates(t *testing.T) {
@@ -63,19 +70,105 @@ func TestParseConfig_DefaultServiceTriggers(t *testing.T) {
 }
 
 func TestReplaceStringSecret_KeyPresent(t *testing.T) {
-	val := replaceStringSecret("hello $secret-value", map[string][]byte{
+	resolver := &referenceResolver{secret: &v1.Secret{Data: map[string][]byte{
 		"secret-value": []byte("world"),
-	})
+	}}}
+	val, err := resolver.resolve("hello $secret-value")
 
+	assert.NoError(t, err)
 	assert.Equal(t, "hello world", val)
 }
 
 func TestReplaceStringSecret_KeyMissing(t *testing.T) {
-	val := replaceStringSecret("hello $secret-value", map[string][]byte{
+	resolver := &referenceResolver{secret: &v1.Secret{Data: map[string][]byte{
 		"another-secret-value": []byte("world"),
-	})
+	}}}
+	val, err := resolver.resolve("hello $secret-value")
 
+	assert.NoError(t, err)
 	assert.Equal(t, "hello $secret-value", val)
+
+	resolver.strict = true
+	_, err = resolver.resolve("hello $secret-value")
+
+	assert.EqualError(t, err, "config referenced '$secret-value', but key does not exist in secret")
+}
+
+func TestReplaceStringSecret_EscapedDollar(t *testing.T) {
+	resolver := &referenceResolver{secret: emptySecret}
+	val, err := resolver.resolve("pa$$word costs $ 5 $")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pa$word costs $ 5 $", val)
+}
+
+func TestReplaceStringSecret_OtherSecret(t *testing.T) {
+	resolver := &referenceResolver{secret: emptySecret, getSecret: func(name string) (*v1.Secret, error) {
+		if name != "other-secret" {
+			return nil, fmt.Errorf("secret %s not found", name)
+		}
+		return &v1.Secret{Data: map[string][]byte{"token": []byte("abc")}}, nil
+	}, strict: true}
+	val, err := resolver.resolve("Bearer $(secret:other-secret:token)")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer abc", val)
+
+	_, err = resolver.resolve("$(secret:other-secret:missing)")
+	assert.EqualError(t, err, "config referenced '$(secret:other-secret:missing)', but key does not exist in secret")
+
+	_, err = resolver.resolve("$(secret:missing:token)")
+	assert.Error(t, err)
+}
+
+func TestReplaceStringSecret_File(t *testing.T) {
+	file, err := ioutil.TempFile("", "token")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	_, err = file.WriteString("file-token\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	resolver := &referenceResolver{secret: emptySecret, strict: true}
+	val, err := resolver.resolve(fmt.Sprintf("$(file:%s)", file.Name()))
+	assert.NoError(t, err)
+	assert.Equal(t, "file-token", val)
+
+	_, err = resolver.resolve("$(file:/does/not/exist)")
+	assert.Error(t, err)
+}
+
+func TestReplaceStringSecret_Env(t *testing.T) {
+	t.Setenv("NOTIFICATIONS_TEST_TOKEN", "env-token")
+
+	resolver := &referenceResolver{secret: emptySecret, strict: true}
+	val, err := resolver.resolve("$(env:NOTIFICATIONS_TEST_TOKEN)")
+	assert.NoError(t, err)
+	assert.Equal(t, "env-token", val)
+
+	_, err = resolver.resolve("$(env:NOTIFICATIONS_TEST_MISSING)")
+	assert.Error(t, err)
+
+	resolver.strict = false
+	val, err = resolver.resolve("$(env:NOTIFICATIONS_TEST_MISSING)")
+	assert.NoError(t, err)
+	assert.Equal(t, "$(env:NOTIFICATIONS_TEST_MISSING)", val)
+}
+
+func TestReplaceStringSecret_InvalidReference(t *testing.T) {
+	resolver := &referenceResolver{secret: emptySecret}
+
+	_, err := resolver.resolve("$(env:TOKEN")
+	assert.Error(t, err)
+
+	_, err = resolver.resolve("$(vault:TOKEN)")
+	assert.Error(t, err)
+
+	_, err = resolver.resolve("$(secret:no-key)")
+	assert.Error(t, err)
 }
 
 func TestReplaceServiceConfigSecrets_WithBasicWebhook_ReplacesSecrets(t *testing.T) {
@@ -98,7 +191,7 @@ headers:
       value: Bearer token
 `
 
-	result, err := replaceServiceConfigSecrets(input, &secrets)
+	result, err := replaceServiceConfigSecrets(input, &referenceResolver{secret: &secrets})
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, string(result))
@@ -124,7 +217,7 @@ apiKeys:
     second-team: second-token
 `
 
-	result, err := replaceServiceConfigSecrets(input, &secrets)
+	result, err := replaceServiceConfigSecrets(input, &referenceResolver{secret: &secrets})
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, string(result))
@@ -152,7 +245,7 @@ privateKey: |-
 installationID: 67890
 `
 
-	result, err := replaceServiceConfigSecrets(input, &secrets)
+	result, err := replaceServiceConfigSecrets(input, &referenceResolver{secret: &secrets})
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, string(result))
@@ -193,3 +286,213 @@ func TestParseConfig_Subscriptions(t *testing.T) {
 		{Triggers: []string{"my-trigger2"}, Selector: label},
 	}), cfg.Subscriptions)
 }
+
+func TestParseConfig_UnresolvedSecretReference(t *testing.T) {
+	_, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"service.slack": `
+token: $slack-token
+`}}, emptySecret)
+
+	assert.EqualError(t, err, "failed to render service configuration slack: config referenced '$slack-token', but key does not exist in secret")
+}
+
+func TestParseConfig_Partials(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"partial.footer": `sent by {{.sender}}`,
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string]string{"footer": "sent by {{.sender}}"}, cfg.Partials)
+}
+
+func TestParseConfig_TemplateLimits(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"templateLimits": `
+timeout: 10s
+maxFieldSize: 1000
+`,
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, templates.Limits{
+		Timeout:      10 * time.Second,
+		MaxFieldSize: 1000,
+		MaxListSize:  templates.DefaultLimits.MaxListSize,
+	}, cfg.TemplateLimits)
+}
+
+func TestParseConfig_StrictTriggers(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"strictTriggers": "true",
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, cfg.StrictTriggers)
+}
+
+func TestParseConfig_TriggerMode(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"trigger.my-trigger": `
+mode: firstMatch
+conditions:
+- when: app.status == 'Degraded'
+  send: [critical]
+- when: app.status == 'Progressing'
+  send: [info]
+`,
+		"trigger.other-trigger": `
+- when: app.status == 'Healthy'
+  send: [info]
+`,
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, cfg.Triggers["my-trigger"], 2)
+	assert.Len(t, cfg.Triggers["other-trigger"], 1)
+	assert.Equal(t, map[string]triggers.Mode{"my-trigger": triggers.ModeFirstMatch}, cfg.TriggerModes)
+}
+
+func TestParseConfig_SuppressInitialSync(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"trigger.my-trigger": `
+suppressInitialSync: true
+conditions:
+- when: app.status == 'Degraded'
+  send: [critical]
+`,
+		"trigger.other-trigger": `
+- when: app.status == 'Healthy'
+  send: [info]
+`,
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string]bool{"my-trigger": true}, cfg.SuppressInitialSync)
+}
+
+func TestParseConfig_TriggerTests(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"trigger.my-trigger": `
+conditions:
+- id: degraded
+  when: app.status == 'Degraded'
+  send: [critical]
+tests:
+- name: degraded app
+  object: {status: Degraded}
+  triggered: [degraded]
+`,
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string][]triggers.TestCase{"my-trigger": {{
+		Name:      "degraded app",
+		Object:    map[string]interface{}{"status": "Degraded"},
+		Triggered: []string{"degraded"},
+	}}}, cfg.TriggerTests)
+}
+
+func TestParseConfig_RecipientGroups(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"recipientGroups": `
+platform-team: [slack:platform, email:platform@example.com]
+`,
+	}}, emptySecret)
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string][]string{"platform-team": {"slack:platform", "email:platform@example.com"}}, cfg.RecipientGroups)
+
+	_, err = ParseConfig(&v1.ConfigMap{Data: map[string]string{
+		"recipientGroups": `
+platform-team: [group:other-team]
+`,
+	}}, emptySecret)
+	assert.Error(t, err)
+}
+
+func TestExpandRecipientGroups(t *testing.T) {
+	cfg := Config{RecipientGroups: map[string][]string{
+		"platform-team": {"slack:platform", "email:platform@example.com"},
+	}}
+	options := &services.DestinationOptions{Severity: "critical"}
+
+	dests := cfg.ExpandRecipientGroups(services.Destinations{
+		"my-trigger": {
+			{Service: "group", Recipient: "platform-team", Locale: "de", Options: options},
+			{Service: "slack", Recipient: "other"},
+			{Service: "group", Recipient: "unknown"},
+		},
+	})
+
+	assert.Equal(t, services.Destinations{
+		"my-trigger": {
+			{Service: "slack", Recipient: "platform", Locale: "de", Options: options},
+			{Service: "email", Recipient: "platform@example.com", Locale: "de", Options: options},
+			{Service: "slack", Recipient: "other"},
+		},
+	}, dests)
+}
+
+func TestGetGlobalDestinationsInNamespace(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"subscriptions": `
+- recipients: [slack:team-a]
+  triggers: [my-trigger]
+  namespaceSelector: team=a
+- recipients: [slack:all]
+  triggers: [my-trigger]`,
+		},
+	}, emptySecret)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, services.Destinations{
+		"my-trigger": {{Service: "slack", Recipient: "team-a"}, {Service: "slack", Recipient: "all"}},
+	}, cfg.GetGlobalDestinationsInNamespace(map[string]string{}, map[string]string{"team": "a"}))
+	assert.Equal(t, services.Destinations{
+		"my-trigger": {{Service: "slack", Recipient: "all"}},
+	}, cfg.GetGlobalDestinationsInNamespace(map[string]string{}, map[string]string{"team": "b"}))
+	assert.Equal(t, services.Destinations{
+		"my-trigger": {{Service: "slack", Recipient: "all"}},
+	}, cfg.GetGlobalDestinations(map[string]string{}))
+}
+
+func TestGetGlobalDestinations_RecipientWithColons(t *testing.T) {
+	cfg, err := ParseConfig(&v1.ConfigMap{
+		Data: map[string]string{
+			"subscriptions": `
+- recipients: ["matrix:@ops:example.com"]
+  triggers: [my-trigger]`,
+		},
+	}, emptySecret)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, services.Destinations{
+		"my-trigger": {{Service: "matrix", Recipient: "@ops:example.com"}},
+	}, cfg.GetGlobalDestinations(map[string]string{}))
+}
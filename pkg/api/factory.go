@@ -2,6 +2,7 @@ package api
 
 import (
 	"sync"
+	texttemplate "text/template"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -18,6 +19,13 @@ type Settings struct {
 	SecretName string
 	// InitGetVars returns a function that produces notifications context variables
 	InitGetVars func(cfg *Config, configMap *v1.ConfigMap, secret *v1.Secret) (GetVars, error)
+	// TemplateFuncs holds additional functions available in notification templates
+	TemplateFuncs texttemplate.FuncMap
+	// ExprFuncs holds additional functions available in trigger expressions
+	ExprFuncs map[string]interface{}
+	// IgnoreUnresolvedReferences keeps references to missing Secret keys, Secrets, files or environment variables in
+	// the service configuration instead of failing, e.g. to render templates without the Secret
+	IgnoreUnresolvedReferences bool
 }
 
 // Factory creates an API instance
@@ -32,6 +40,8 @@ type apiFactory struct {
 	secretLister v1listers.SecretNamespaceLister
 	lock         sync.Mutex
 	api          API
+	// referencedSecrets holds names of Secrets referenced by the service configuration of the cached api
+	referencedSecrets map[string]bool
 }
 
 func NewFactory(settings Settings, namespace string, secretsInformer cache.SharedIndexInformer, cmInformer cache.SharedIndexInformer) *apiFactory {
@@ -43,13 +53,13 @@ func NewFactory(settings Settings, namespace string, secretsInformer cache.Share
 
 	secretsInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			factory.invalidateIfHasName(settings.SecretName, obj)
+			factory.invalidateIfReferencedSecret(obj)
 		},
 		DeleteFunc: func(obj interface{}) {
-			factory.invalidateIfHasName(settings.SecretName, obj)
+			factory.invalidateIfReferencedSecret(obj)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			factory.invalidateIfHasName(settings.SecretName, newObj)
+			factory.invalidateIfReferencedSecret(newObj)
 		}})
 	cmInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -74,6 +84,25 @@ func (f *apiFactory) invalidateIfHasName(name string, obj interface{}) {
 	}
 }
 
+func (f *apiFactory) invalidateIfReferencedSecret(obj interface{}) {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if metaObj.GetName() == f.SecretName || f.referencedSecrets[metaObj.GetName()] {
+		f.api = nil
+	}
+}
+
+// getReferencedSecret returns the Secret referenced by the service configuration and remembers its name so that
+// the cached api is invalidated when the Secret changes. Must be called while holding the lock.
+func (f *apiFactory) getReferencedSecret(name string) (*v1.Secret, error) {
+	f.referencedSecrets[name] = true
+	return f.secretLister.Get(name)
+}
+
 func (f *apiFactory) getConfigMapAndSecret() (*v1.ConfigMap, *v1.Secret, error) {
 	cm, err := f.cmLister.Get(f.ConfigMapName)
 	if err != nil {
@@ -110,10 +139,17 @@ func (f *apiFactory) GetAPI() (API, error) {
 		if err != nil {
 			return nil, err
 		}
-		cfg, err := ParseConfig(cm, secret)
+		f.referencedSecrets = map[string]bool{}
+		cfg, err := parseConfig(cm, &referenceResolver{
+			secret:    secret,
+			getSecret: f.getReferencedSecret,
+			strict:    !f.IgnoreUnresolvedReferences,
+		})
 		if err != nil {
 			return nil, err
 		}
+		cfg.TemplateFuncs = f.TemplateFuncs
+		cfg.ExprFuncs = f.ExprFuncs
 		getVars, err := f.InitGetVars(cfg, cm, secret)
 		if err != nil {
 			return nil, err
@@ -71,3 +71,38 @@ func TestGetAPI(t *testing.T) {
 	assert.Len(t, svcs, 1)
 	assert.NotNil(t, svcs["email"])
 }
+
+func TestGetAPI_ReferencedSecret(t *testing.T) {
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config-map", Namespace: "default"},
+		Data: map[string]string{
+			"service.slack": `{"token": "$(secret:other-secret:token)"}`,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-secret", Namespace: "default"},
+	}
+	otherSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-secret", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("abc")},
+	}
+
+	clientset := fake.NewSimpleClientset(cm, secret, otherSecret)
+	informerFactory := informers.NewSharedInformerFactory(clientset, time.Minute)
+
+	secrets := informerFactory.Core().V1().Secrets().Informer()
+	configMaps := informerFactory.Core().V1().ConfigMaps().Informer()
+	factory := NewFactory(settings, "default", secrets, configMaps)
+
+	go informerFactory.Start(context.Background().Done())
+	if !cache.WaitForCacheSync(context.Background().Done(), configMaps.HasSynced, secrets.HasSynced) {
+		assert.Fail(t, "failed to sync informers")
+	}
+
+	_, err := factory.GetAPI()
+	require.NoError(t, err)
+	assert.True(t, factory.referencedSecrets["other-secret"])
+
+	factory.invalidateIfReferencedSecret(otherSecret)
+	assert.Nil(t, factory.api)
+}
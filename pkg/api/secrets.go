@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// SecretGetter returns the Secret with the specified name
+type SecretGetter func(name string) (*v1.Secret, error)
+
+// referenceResolver replaces references to sensitive data in service configuration values. Supported formats:
+//   - $<key> - value of the key in the notifications Secret
+//   - $(secret:<name>:<key>) - value of the key in the Secret with the specified name
+//   - $(file:<path>) - content of the file, e.g. mounted projected service account token
+//   - $(env:<name>) - value of the environment variable
+//   - $$ - literal $ character
+//
+// References to missing keys, Secrets, files or environment variables fail in the strict mode. Otherwise they are
+// logged and kept unchanged, so the config can be rendered without the referenced data, e.g. by the CLI with an empty
+// Secret.
+type referenceResolver struct {
+	secret    *v1.Secret
+	getSecret SecretGetter
+	strict    bool
+}
+
+func isSecretKeyChar(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// resolve returns given value with all references replaced by the referenced data
+func (r *referenceResolver) resolve(val string) (string, error) {
+	var res strings.Builder
+	for i := 0; i < len(val); {
+		if val[i] != '$' || i+1 == len(val) {
+			res.WriteByte(val[i])
+			i++
+			continue
+		}
+		switch next := val[i+1]; {
+		case next == '$':
+			res.WriteByte('$')
+			i += 2
+		case next == '(':
+			end := strings.IndexByte(val[i+2:], ')')
+			if end < 0 {
+				return "", fmt.Errorf("reference '%s' is not terminated with ')'", val[i:])
+			}
+			refVal, err := r.resolveReference(val[i+2 : i+2+end])
+			var unresolved *unresolvedReferenceError
+			if errors.As(err, &unresolved) && !r.strict {
+				log.Warn(err.Error())
+				refVal = val[i : i+3+end]
+			} else if err != nil {
+				return "", err
+			}
+			res.WriteString(refVal)
+			i += end + 3
+		case isSecretKeyChar(next):
+			end := i + 1
+			for end < len(val) && isSecretKeyChar(val[end]) {
+				end++
+			}
+			key := val[i+1 : end]
+			secretVal, ok := r.secret.Data[key]
+			if !ok {
+				if r.strict {
+					return "", fmt.Errorf("config referenced '$%s', but key does not exist in secret", key)
+				}
+				log.Warnf("config referenced '$%s', but key does not exist in secret", key)
+				secretVal = []byte(val[i:end])
+			}
+			res.Write(secretVal)
+			i = end
+		default:
+			res.WriteByte('$')
+			i++
+		}
+	}
+	return res.String(), nil
+}
+
+// unresolvedReferenceError is returned if the referenced data is not available
+type unresolvedReferenceError struct {
+	message string
+}
+
+func (e *unresolvedReferenceError) Error() string {
+	return e.message
+}
+
+func unresolvedReference(format string, args ...interface{}) error {
+	return &unresolvedReferenceError{message: fmt.Sprintf(format, args...)}
+}
+
+func (r *referenceResolver) resolveReference(ref string) (string, error) {
+	parts := strings.SplitN(ref, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("invalid reference '$(%s)'; expected '$(<secret|file|env>:<value>)'", ref)
+	}
+	switch kind, val := parts[0], parts[1]; kind {
+	case "secret":
+		nameAndKey := strings.SplitN(val, ":", 2)
+		if len(nameAndKey) != 2 {
+			return "", fmt.Errorf("invalid secret reference '$(%s)'; expected '$(secret:<name>:<key>)'", ref)
+		}
+		if r.getSecret == nil {
+			return "", unresolvedReference("config referenced '$(%s)', but referencing other secrets is not supported", ref)
+		}
+		secret, err := r.getSecret(nameAndKey[0])
+		if err != nil {
+			return "", unresolvedReference("config referenced '$(%s)', but secret is not available: %v", ref, err)
+		}
+		secretVal, ok := secret.Data[nameAndKey[1]]
+		if !ok {
+			return "", unresolvedReference("config referenced '$(%s)', but key does not exist in secret", ref)
+		}
+		return string(secretVal), nil
+	case "file":
+		data, err := ioutil.ReadFile(val)
+		if err != nil {
+			return "", unresolvedReference("config referenced '$(%s)', but file cannot be read: %v", ref, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case "env":
+		envVal, ok := os.LookupEnv(val)
+		if !ok {
+			return "", unresolvedReference("config referenced '$(%s)', but environment variable is not set", ref)
+		}
+		return envVal, nil
+	default:
+		return "", fmt.Errorf("config referenced '$(%s)', but reference type '%s' is not supported", ref, kind)
+	}
+}
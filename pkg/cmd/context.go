@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/ghodss/yaml"
@@ -103,6 +104,61 @@ func (c *commandContext) loadResource(name string) (*unstructured.Unstructured,
 	return res, nil
 }
 
+// loadResources loads resources from the file, the directory, or the cluster. Live resources are selected by name or
+// by the label selector in the current namespace or in all namespaces.
+func (c *commandContext) loadResources(name string, selector string, allNamespaces bool) ([]*unstructured.Unstructured, error) {
+	if fileinfo, err := os.Stat(name); err == nil {
+		var paths []string
+		if fileinfo.IsDir() {
+			entries, err := ioutil.ReadDir(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json") {
+					paths = append(paths, filepath.Join(name, entry.Name()))
+				}
+			}
+		} else {
+			paths = append(paths, name)
+		}
+		var res []*unstructured.Unstructured
+		for _, path := range paths {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			objs, err := splitYAML(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+			res = append(res, objs...)
+		}
+		return res, nil
+	}
+
+	if name != "" {
+		res, err := c.dynamicClient.Resource(c.resource).Namespace(c.namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{res}, nil
+	}
+	namespace := c.namespace
+	if allNamespaces {
+		namespace = ""
+	}
+	list, err := c.dynamicClient.Resource(c.resource).Namespace(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var res []*unstructured.Unstructured
+	for i := range list.Items {
+		res = append(res, &list.Items[i])
+	}
+	return res, nil
+}
+
 func (c *commandContext) getSecret() (*v1.Secret, error) {
 	var secret v1.Secret
 	if c.secretPath == ":empty" {
@@ -148,6 +204,14 @@ func (c *commandContext) getConfigMap() (*v1.ConfigMap, error) {
 }
 
 func (c *commandContext) getAPI() (api.API, error) {
+	factory, err := c.getAPIFactory()
+	if err != nil {
+		return nil, err
+	}
+	return factory.GetAPI()
+}
+
+func (c *commandContext) getAPIFactory() (api.Factory, error) {
 	secretInformer := informersv1.NewSecretInformer(c.k8sClient, c.namespace, time.Minute*3, cache.Indexers{})
 	s, err := c.getSecret()
 	if err != nil {
@@ -165,5 +229,8 @@ func (c *commandContext) getAPI() (api.API, error) {
 		return nil, err
 	}
 
-	return api.NewFactory(c.Settings, c.namespace, secretInformer, cmInformer).GetAPI()
+	settings := c.Settings
+	// the empty secret does not hold the referenced keys
+	settings.IgnoreUnresolvedReferences = c.secretPath == ":empty"
+	return api.NewFactory(settings, c.namespace, secretInformer, cmInformer), nil
 }
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"io/ioutil"
 	"log"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/argoproj/notifications-engine/pkg/api"
 )
@@ -53,3 +56,25 @@ func Test_getSecretFromFile(t *testing.T) {
 	assert.NotEmpty(t, secret)
 	assert.Equal(t, secret.Name, "argocd-notifications-secret")
 }
+
+func Test_getAPI_EmptySecretKeepsUnresolvedReferences(t *testing.T) {
+	ctx, closer, err := newTestContext(ioutil.Discard, ioutil.Discard, map[string]string{
+		"service.slack": `token: $slack-token`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	notificationsAPI, err := ctx.getAPI()
+	assert.NoError(t, err)
+	assert.Contains(t, notificationsAPI.GetNotificationServices(), "slack")
+
+	ctx.secretPath = ""
+	_, err = ctx.k8sClient.CoreV1().Secrets("default").Create(context.Background(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: ctx.SecretName},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	_, err = ctx.getAPI()
+	assert.EqualError(t, err, "failed to render service configuration slack: config referenced '$slack-token', but key does not exist in secret")
+}
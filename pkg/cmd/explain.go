@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	informersv1 "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/argoproj/notifications-engine/pkg/controller"
+	"github.com/argoproj/notifications-engine/pkg/util/misc"
+)
+
+func newExplainCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output string
+	)
+	var command = cobra.Command{
+		Use: "explain RESOURCE_NAME",
+		Example: fmt.Sprintf(`
+# Explain why notifications about the resource are or are not sent
+%s explain guestbook
+
+# Explain the resource manifest using my-config-map.yaml instead of '%s' ConfigMap
+%s explain ./sample-app.yaml --config-map ./my-config-map.yaml -o yaml
+`, cmdContext.cliName, cmdContext.ConfigMapName, cmdContext.cliName),
+		Short: "Prints destinations, condition results and notified state of the resource and whether the notifications would be sent now",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			factory, err := cmdContext.getAPIFactory()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get api: %v\n", err)
+				return nil
+			}
+			res, err := cmdContext.loadResource(args[0])
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load resource: %v\n", err)
+				return nil
+			}
+			var opts []controller.Opts
+			namespaceName := res.GetNamespace()
+			if namespaceName == "" {
+				namespaceName = cmdContext.namespace
+			}
+			if ns, err := cmdContext.k8sClient.CoreV1().Namespaces().Get(context.Background(), namespaceName, metav1.GetOptions{}); err == nil {
+				nsInformer := informersv1.NewNamespaceInformer(cmdContext.k8sClient, time.Minute*3, cache.Indexers{})
+				if err := nsInformer.GetStore().Add(ns); err == nil {
+					opts = append(opts, controller.WithNamespaceInformer(nsInformer))
+				}
+			}
+			explanation, err := controller.ExplainResource(res, factory, opts...)
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to explain resource: %v\n", err)
+				return nil
+			}
+
+			switch output {
+			case "", "wide":
+				printExplanation(cmdContext, explanation)
+			default:
+				return misc.PrintFormatted(explanation, output, cmdContext.stdout)
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVarP(&output, "output", "o", "wide", "Output format. One of:json|yaml|wide")
+	return &command
+}
+
+func printExplanation(cmdContext *commandContext, explanation *controller.Explanation) {
+	if explanation.Skipped != "" {
+		_, _ = fmt.Fprintf(cmdContext.stdout, "Processing is skipped: %s\n", explanation.Skipped)
+		return
+	}
+	if len(explanation.Triggers) == 0 {
+		_, _ = fmt.Fprintln(cmdContext.stdout, "Resource is not subscribed to any trigger")
+	}
+
+	w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "TRIGGER\tDESTINATION\tSOURCE\n")
+	for _, trigger := range explanation.Triggers {
+		for _, dest := range trigger.Destinations {
+			_, _ = fmt.Fprintf(w, "%s\t%s:%s\t%s\n", trigger.Trigger, dest.Destination.Service, dest.Destination.Recipient, strings.Join(dest.Sources, ", "))
+		}
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintln(cmdContext.stdout)
+	w = tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "TRIGGER\tCONDITION\tTRIGGERED\tDESTINATION\tSEND\tREASON\n")
+	for _, trigger := range explanation.Triggers {
+		if trigger.Error != "" {
+			_, _ = fmt.Fprintf(w, "%s\t\t\t\t\tfailed to evaluate trigger: %s\n", trigger.Trigger, trigger.Error)
+		}
+		for _, condition := range trigger.Conditions {
+			triggered := fmt.Sprintf("%v", condition.Triggered)
+			if condition.Error != "" {
+				triggered = fmt.Sprintf("%v (error: %s)", condition.Triggered, condition.Error)
+			}
+			for _, delivery := range condition.Deliveries {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s:%s\t%v\t%s\n", trigger.Trigger, condition.Key, triggered,
+					delivery.Destination.Service, delivery.Destination.Recipient, delivery.WouldSend, delivery.Reason)
+			}
+		}
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintln(cmdContext.stdout)
+	w = tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "STATE KEY\tNOTIFIED AT\n")
+	misc.IterateStringKeyMap(explanation.State, func(key string) {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", key, time.Unix(explanation.State[key], 0).UTC().Format(time.RFC3339))
+	})
+	_ = w.Flush()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+)
+
+func TestExplain(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+- when: app.metadata.name == 'guestbook'
+  send: [my-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+	}
+	app := newTestResource("guestbook")
+	app.SetAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "slack"): "my-channel",
+	})
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, app)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newExplainCommand(ctx)
+	err = command.RunE(command, []string{"guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "my-trigger  slack:my-channel  annotation")
+	assert.Contains(t, stdout.String(), "condition is triggered and destination is not notified yet")
+}
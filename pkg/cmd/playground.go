@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj/notifications-engine/pkg/api"
+	"github.com/argoproj/notifications-engine/pkg/controller"
+	"github.com/argoproj/notifications-engine/pkg/services"
+	"github.com/argoproj/notifications-engine/pkg/templatetest"
+	"github.com/argoproj/notifications-engine/pkg/triggers"
+)
+
+// playgroundMaxResourceSize is the maximum size of the resource posted to the render endpoint
+const playgroundMaxResourceSize = 1 << 20
+
+// playgroundRender holds the notification rendered for the service or the rendering errors
+type playgroundRender struct {
+	Service      string                 `json:"service"`
+	Condition    string                 `json:"condition,omitempty"`
+	Notification *services.Notification `json:"notification,omitempty"`
+	Errors       []string               `json:"errors,omitempty"`
+}
+
+// newPlaygroundHandler returns the handler of the template playground. The config is loaded on every request, so
+// changes of the ConfigMap file are picked up without restart. Resource files are listed from the given directory.
+func newPlaygroundHandler(cmdContext *commandContext, resourcesDir string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(playgroundPage))
+	})
+	mux.HandleFunc("/api/templates", func(w http.ResponseWriter, r *http.Request) {
+		api, err := cmdContext.getAPI()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to get api: %v", err), http.StatusInternalServerError)
+			return
+		}
+		names := []string{}
+		for name := range api.GetConfig().Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writeJSON(w, names)
+	})
+	mux.HandleFunc("/api/triggers", func(w http.ResponseWriter, r *http.Request) {
+		api, err := cmdContext.getAPI()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to get api: %v", err), http.StatusInternalServerError)
+			return
+		}
+		names := []string{}
+		for name := range api.GetConfig().Triggers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writeJSON(w, names)
+	})
+	mux.HandleFunc("/api/resources", func(w http.ResponseWriter, r *http.Request) {
+		names, err := listResourceFiles(resourcesDir)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to list resources: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, names)
+	})
+	mux.HandleFunc("/api/resource", func(w http.ResponseWriter, r *http.Request) {
+		data, err := readResourceFile(resourcesDir, r.URL.Query().Get("name"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read resource: %v", err), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/api/render", func(w http.ResponseWriter, r *http.Request) {
+		api, err := cmdContext.getAPI()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to get api: %v", err), http.StatusInternalServerError)
+			return
+		}
+		var data []byte
+		if r.Method == http.MethodPost {
+			data, err = ioutil.ReadAll(http.MaxBytesReader(w, r.Body, playgroundMaxResourceSize))
+		} else {
+			data, err = readResourceFile(resourcesDir, r.URL.Query().Get("resource"))
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read resource: %v", err), http.StatusBadRequest)
+			return
+		}
+		var res unstructured.Unstructured
+		if err := yaml.Unmarshal(data, &res.Object); err != nil {
+			http.Error(w, fmt.Sprintf("failed to unmarshal resource: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		cfg := api.GetConfig()
+		serviceNames := r.URL.Query()["service"]
+		if len(serviceNames) == 0 {
+			for name := range cfg.Services {
+				serviceNames = append(serviceNames, name)
+			}
+			sort.Strings(serviceNames)
+		}
+		if len(serviceNames) == 0 {
+			serviceNames = []string{"console"}
+		}
+
+		results := []playgroundRender{}
+		trigger := r.URL.Query().Get("trigger")
+		if trigger == "" {
+			template := r.URL.Query().Get("template")
+			for _, service := range serviceNames {
+				results = append(results, renderPlayground(api, res.Object, playgroundRender{Service: service}, []string{template}))
+			}
+			writeJSON(w, results)
+			return
+		}
+
+		conditions, err := api.RunTrigger(trigger, res.Object)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to run trigger: %v", err), http.StatusBadRequest)
+			return
+		}
+		for _, cr := range conditions {
+			for _, service := range serviceNames {
+				item := playgroundRender{Service: service, Condition: triggers.ConditionName(cr)}
+				templates := controller.DestinationTemplates(cfg, cr, services.Destination{Service: service})
+				if len(templates) == 0 {
+					item.Errors = append(item.Errors, "no templates configured for the service")
+					results = append(results, item)
+					continue
+				}
+				results = append(results, renderPlayground(api, res.Object, item, templates))
+			}
+		}
+		writeJSON(w, results)
+	})
+	return mux
+}
+
+// renderPlayground renders the templates for the service of the item and validates JSON fields of the notification
+func renderPlayground(api api.API, obj map[string]interface{}, item playgroundRender, templates []string) playgroundRender {
+	notification, err := api.Render(obj, templates, services.Destination{Service: item.Service})
+	if err != nil {
+		item.Errors = append(item.Errors, err.Error())
+		return item
+	}
+	item.Notification = notification
+	for _, err := range templatetest.ValidateJSONFields(*notification) {
+		item.Errors = append(item.Errors, err.Error())
+	}
+	return item
+}
+
+func writeJSON(w http.ResponseWriter, val interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(val)
+}
+
+// listResourceFiles returns names of the YAML and JSON files in the directory
+func listResourceFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// readResourceFile reads the resource file of the directory, the name must not reference other directories
+func readResourceFile(dir string, name string) ([]byte, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid resource file name '%s'", name)
+	}
+	return ioutil.ReadFile(filepath.Join(dir, name))
+}
+
+const playgroundPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Template Playground</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; height: 100vh; }
+#editor { width: 40%; padding: 10px; display: flex; flex-direction: column; box-sizing: border-box; }
+#editor textarea { flex: 1; font-family: monospace; }
+#output { flex: 1; padding: 10px; overflow: auto; }
+pre { background: #f5f5f5; padding: 8px; overflow: auto; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<div id="editor">
+  <label>Trigger <select id="trigger"></select></label>
+  <label>Template <select id="template"></select></label>
+  <label>Resource file <select id="resource"></select></label>
+  <p>Resource (edit to re-render)</p>
+  <textarea id="resourceText"></textarea>
+</div>
+<div id="output"></div>
+<script>
+const $ = id => document.getElementById(id);
+
+async function fill(select, url, empty) {
+  const items = await (await fetch(url)).json();
+  select.innerHTML = '';
+  if (empty) {
+    items.unshift('');
+  }
+  for (const item of items) {
+    const option = document.createElement('option');
+    option.textContent = item;
+    select.appendChild(option);
+  }
+}
+
+async function render() {
+  const query = $('trigger').value ? 'trigger=' + encodeURIComponent($('trigger').value) : 'template=' + encodeURIComponent($('template').value);
+  const res = await fetch('/api/render?' + query, {
+    method: 'POST',
+    body: $('resourceText').value,
+  });
+  const output = $('output');
+  if (!res.ok) {
+    output.innerHTML = '<p class="error"></p>';
+    output.firstChild.textContent = await res.text();
+    return;
+  }
+  output.innerHTML = '';
+  for (const item of await res.json()) {
+    const section = document.createElement('section');
+    const title = document.createElement('h3');
+    title.textContent = item.condition ? item.service + ' (condition ' + item.condition + ')' : item.service;
+    section.appendChild(title);
+    for (const err of item.errors || []) {
+      const p = document.createElement('p');
+      p.className = 'error';
+      p.textContent = err;
+      section.appendChild(p);
+    }
+    if (item.notification) {
+      const pre = document.createElement('pre');
+      pre.textContent = JSON.stringify(item.notification, null, 2);
+      section.appendChild(pre);
+    }
+    output.appendChild(section);
+  }
+}
+
+let timer;
+function scheduleRender() {
+  clearTimeout(timer);
+  timer = setTimeout(render, 300);
+}
+
+(async () => {
+  await fill($('trigger'), '/api/triggers', true);
+  await fill($('template'), '/api/templates');
+  await fill($('resource'), '/api/resources');
+  $('trigger').onchange = render;
+  $('template').onchange = render;
+  $('resource').onchange = async () => {
+    $('resourceText').value = await (await fetch('/api/resource?name=' + encodeURIComponent($('resource').value))).text();
+    render();
+  };
+  $('resourceText').oninput = scheduleRender;
+  if ($('resource').value) {
+    $('resource').onchange();
+  }
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaygroundHandler(t *testing.T) {
+	cmData := map[string]string{
+		"template.my-template": `
+message: hello {{.app.metadata.name}}
+slack:
+  blocks: '[{"type": "section",}]'`,
+		"template.short": `message: short {{.app.metadata.name}}`,
+		"trigger.on-created": `
+- when: "true"
+  send:
+    slack: [short]
+    email: [my-template]`,
+	}
+	dir, err := ioutil.TempDir("", "resources")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "guestbook.yaml"), []byte("metadata: {name: guestbook}"), 0644))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+	handler := newPlaygroundHandler(ctx, dir)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	assert.JSONEq(t, `["my-template", "short"]`, get("/api/templates").Body.String())
+	assert.JSONEq(t, `["on-created"]`, get("/api/triggers").Body.String())
+	assert.JSONEq(t, `["guestbook.yaml"]`, get("/api/resources").Body.String())
+	assert.Equal(t, http.StatusBadRequest, get("/api/resource?name=../secret.yaml").Code)
+
+	var results []playgroundRender
+	rec := get("/api/render?template=my-template&resource=guestbook.yaml&service=slack")
+	if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results)) || !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, "slack", results[0].Service)
+	assert.Equal(t, "hello guestbook", results[0].Notification.Message)
+	assert.Equal(t, []string{"slack.blocks is not a valid JSON"}, results[0].Errors)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/render?template=my-template", strings.NewReader("metadata: {name: other}")))
+	if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results)) || !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, "console", results[0].Service)
+	assert.Equal(t, "hello other", results[0].Notification.Message)
+
+	rec = get("/api/render?trigger=on-created&resource=guestbook.yaml&service=slack&service=webhook")
+	if !assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results)) || !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, "[0]", results[0].Condition)
+	assert.Equal(t, "short guestbook", results[0].Notification.Message)
+	assert.Equal(t, "webhook", results[1].Service)
+	assert.Equal(t, []string{"no templates configured for the service"}, results[1].Errors)
+
+	rec = httptest.NewRecorder()
+	body := "metadata: {name: " + strings.Repeat("a", playgroundMaxResourceSize) + "}"
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/render?template=my-template", strings.NewReader(body)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
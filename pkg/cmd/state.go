@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj/notifications-engine/pkg/controller"
+	"github.com/argoproj/notifications-engine/pkg/services"
+	"github.com/argoproj/notifications-engine/pkg/util/misc"
+)
+
+func newStateCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "state",
+		Short: "Notifications state related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newStateGetCommand(cmdContext))
+	command.AddCommand(newStateResetCommand(cmdContext))
+
+	return &command
+}
+
+// stateItem holds the notified state item
+type stateItem struct {
+	Key        string    `json:"key"`
+	NotifiedAt time.Time `json:"notifiedAt"`
+}
+
+func newStateGetCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output string
+	)
+	var command = cobra.Command{
+		Use: "get RESOURCE_NAME",
+		Example: fmt.Sprintf(`
+# Print notifications already sent about the resource
+%s state get guestbook
+`, cmdContext.cliName),
+		Short: "Prints notifications already sent about the resource",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			res, err := cmdContext.loadResource(args[0])
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load resource: %v\n", err)
+				return nil
+			}
+			state := controller.NewStateFromRes(res)
+			var items []stateItem
+			for _, key := range state.Keys(controller.StateFilter{}) {
+				items = append(items, stateItem{Key: key, NotifiedAt: time.Unix(state[key], 0).UTC()})
+			}
+
+			switch output {
+			case "", "wide":
+				w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintf(w, "KEY\tNOTIFIED AT\tAGE\n")
+				for _, item := range items {
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", item.Key, item.NotifiedAt.Format(time.RFC3339), time.Since(item.NotifiedAt).Round(time.Second))
+				}
+				_ = w.Flush()
+			case "name":
+				for _, item := range items {
+					_, _ = fmt.Fprintln(cmdContext.stdout, item.Key)
+				}
+			default:
+				return misc.PrintFormatted(items, output, cmdContext.stdout)
+			}
+			return nil
+		},
+	}
+	addOutputFlags(&command, &output)
+	return &command
+}
+
+func newStateResetCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		trigger   string
+		recipient string
+		olderThan time.Duration
+		all       bool
+	)
+	var command = cobra.Command{
+		Use: "reset RESOURCE_NAME",
+		Example: fmt.Sprintf(`
+# Resend notifications of the on-sync-failed trigger
+%s state reset guestbook --trigger on-sync-failed
+
+# Resend notifications to the Slack channel sent more than a day ago
+%s state reset guestbook --recipient slack:my-channel --older-than 24h
+
+# Remove the whole notifications state of the resource
+%s state reset guestbook --all
+`, cmdContext.cliName, cmdContext.cliName, cmdContext.cliName),
+		Short: "Removes notified state items of the resource, so the notifications are sent again",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			filter := controller.StateFilter{Trigger: trigger}
+			if recipient != "" {
+				service, recipient, err := parseRecipient(recipient)
+				if err != nil {
+					return err
+				}
+				filter.Destination = &services.Destination{Service: service, Recipient: recipient}
+			}
+			if olderThan > 0 {
+				filter.NotifiedBefore = time.Now().Add(-olderThan)
+			}
+			if filter == (controller.StateFilter{}) && !all {
+				return errors.New("at least one of --trigger, --recipient, --older-than or --all flags is required")
+			}
+
+			err := cmdContext.updateAnnotations(args[0], func(res *unstructured.Unstructured, annotations map[string]string) error {
+				state := controller.NewStateFromRes(res)
+				for _, key := range state.Remove(filter) {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "removed %s\n", key)
+				}
+				updated, err := state.Persist(res)
+				if err != nil {
+					return err
+				}
+				for k := range annotations {
+					delete(annotations, k)
+				}
+				for k, v := range updated {
+					annotations[k] = v
+				}
+				return nil
+			})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to update notifications state: %v\n", err)
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVar(&trigger, "trigger", "", "Remove items of the trigger")
+	command.Flags().StringVar(&recipient, "recipient", "", "Remove items of the recipient in the <service>:<recipient> format")
+	command.Flags().DurationVar(&olderThan, "older-than", 0, "Remove items notified earlier than the specified duration ago")
+	command.Flags().BoolVar(&all, "all", false, "Remove all items")
+	return &command
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/notifications-engine/pkg/controller"
+	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+)
+
+func TestStateGetAndReset(t *testing.T) {
+	app := newTestResource("guestbook")
+	app.SetAnnotations(map[string]string{
+		subscriptions.NotifiedAnnotationKey(): `{"on-created:0:slack:my-channel":1,"on-deleted:0:slack:my-channel":2}`,
+	})
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, map[string]string{}, app)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newStateGetCommand(ctx)
+	assert.NoError(t, command.Flags().Set("output", "name"))
+	err = command.RunE(command, []string{"guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, "on-created:0:slack:my-channel\non-deleted:0:slack:my-channel\n", stdout.String())
+
+	command = newStateResetCommand(ctx)
+	err = command.RunE(command, []string{"guestbook"})
+	assert.Error(t, err)
+
+	stdout.Reset()
+	assert.NoError(t, command.Flags().Set("trigger", "on-created"))
+	err = command.RunE(command, []string{"guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, "removed on-created:0:slack:my-channel\n", stdout.String())
+
+	res, err := ctx.dynamicClient.Resource(ctx.resource).Namespace("default").Get(context.Background(), "guestbook", metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, controller.NotificationsState{"on-deleted:0:slack:my-channel": 2}, controller.NewStateFromRes(res))
+}
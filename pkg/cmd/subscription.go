@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/argoproj/notifications-engine/pkg/api"
+	"github.com/argoproj/notifications-engine/pkg/controller"
+	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+	"github.com/argoproj/notifications-engine/pkg/util/misc"
+)
+
+func newSubscriptionCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use:   "subscription",
+		Short: "Notification subscriptions related commands",
+		RunE: func(c *cobra.Command, args []string) error {
+			return errors.New("select child command")
+		},
+	}
+	command.AddCommand(newSubscriptionAddCommand(cmdContext))
+	command.AddCommand(newSubscriptionRemoveCommand(cmdContext))
+	command.AddCommand(newSubscriptionListCommand(cmdContext))
+
+	return &command
+}
+
+// parseRecipient parses the recipient in the <service>:<recipient> format
+func parseRecipient(recipient string) (string, string, error) {
+	parts := strings.SplitN(recipient, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("recipient '%s' is not in the <service>:<recipient> format", recipient)
+	}
+	return parts[0], parts[1], nil
+}
+
+func newSubscriptionAddCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		trigger string
+	)
+	var command = cobra.Command{
+		Use: "add RESOURCE_NAME SERVICE:RECIPIENT...",
+		Example: fmt.Sprintf(`
+# Subscribe Slack channel to the on-sync-failed trigger
+%s subscription add guestbook slack:my-channel --trigger on-sync-failed
+
+# Subscribe recipient to the default triggers in the resource manifest
+%s subscription add ./guestbook.yaml email:ops@example.com
+`, cmdContext.cliName, cmdContext.cliName),
+		Short: "Subscribes recipients to the notifications about the resource",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("expected at least two arguments, got %d", len(args))
+			}
+			err := cmdContext.updateAnnotations(args[0], func(res *unstructured.Unstructured, annotations map[string]string) error {
+				for _, recipient := range args[1:] {
+					service, recipient, err := parseRecipient(recipient)
+					if err != nil {
+						return err
+					}
+					subscriptions.NewAnnotations(annotations).Subscribe(trigger, service, recipient)
+				}
+				return nil
+			})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to update subscriptions: %v\n", err)
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVar(&trigger, "trigger", "", "Trigger name. The default triggers are used if not specified")
+	return &command
+}
+
+func newSubscriptionRemoveCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		trigger string
+	)
+	var command = cobra.Command{
+		Use: "remove RESOURCE_NAME SERVICE:RECIPIENT...",
+		Example: fmt.Sprintf(`
+# Unsubscribe Slack channel from the on-sync-failed trigger
+%s subscription remove guestbook slack:my-channel --trigger on-sync-failed
+`, cmdContext.cliName),
+		Short: "Unsubscribes recipients from the notifications about the resource",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return fmt.Errorf("expected at least two arguments, got %d", len(args))
+			}
+			err := cmdContext.updateAnnotations(args[0], func(res *unstructured.Unstructured, annotations map[string]string) error {
+				for _, recipient := range args[1:] {
+					service, recipient, err := parseRecipient(recipient)
+					if err != nil {
+						return err
+					}
+					before := len(annotations[subscriptions.SubscribeAnnotationKey(trigger, service)])
+					subscriptions.NewAnnotations(annotations).Unsubscribe(trigger, service, recipient)
+					if len(annotations[subscriptions.SubscribeAnnotationKey(trigger, service)]) == before {
+						_, _ = fmt.Fprintf(cmdContext.stderr, "%s:%s is not subscribed using the '%s' annotation\n",
+							service, recipient, subscriptions.SubscribeAnnotationKey(trigger, service))
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to update subscriptions: %v\n", err)
+			}
+			return nil
+		},
+	}
+	command.Flags().StringVar(&trigger, "trigger", "", "Trigger name. The default triggers are used if not specified")
+	return &command
+}
+
+// updateAnnotations updates annotations of the live resource or the resource manifest file
+func (c *commandContext) updateAnnotations(resourceName string, update func(res *unstructured.Unstructured, annotations map[string]string) error) error {
+	res, err := c.loadResource(resourceName)
+	if err != nil {
+		return fmt.Errorf("failed to load resource: %v", err)
+	}
+	original := map[string]string{}
+	annotations := map[string]string{}
+	for k, v := range res.GetAnnotations() {
+		original[k] = v
+		annotations[k] = v
+	}
+	if err := update(res, annotations); err != nil {
+		return err
+	}
+
+	if fileinfo, err := os.Stat(resourceName); err == nil && !fileinfo.IsDir() {
+		res.SetAnnotations(annotations)
+		data, err := yaml.Marshal(res.Object)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(resourceName, data, fileinfo.Mode())
+	}
+
+	patchAnnotations := map[string]interface{}{}
+	for k, v := range annotations {
+		if original[k] != v {
+			patchAnnotations[k] = v
+		}
+	}
+	for k := range original {
+		if _, ok := annotations[k]; !ok {
+			patchAnnotations[k] = nil
+		}
+	}
+	if len(patchAnnotations) == 0 {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": patchAnnotations}})
+	if err != nil {
+		return err
+	}
+	_, err = c.dynamicClient.Resource(c.resource).Namespace(res.GetNamespace()).Patch(
+		context.Background(), res.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// subscriptionDestination holds the effective destination and the subscriptions it comes from
+type subscriptionDestination struct {
+	Trigger   string   `json:"trigger"`
+	Service   string   `json:"service"`
+	Recipient string   `json:"recipient"`
+	Sources   []string `json:"sources"`
+}
+
+func newSubscriptionListCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		output                 string
+		namespaceSubscriptions bool
+	)
+	var command = cobra.Command{
+		Use: "list RESOURCE_NAME",
+		Example: fmt.Sprintf(`
+# Print effective destinations of the resource and their sources
+%s subscription list guestbook
+`, cmdContext.cliName),
+		Short: "Prints effective notification destinations of the resource and explains where they come from",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			api, err := cmdContext.getAPI()
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get api: %v\n", err)
+				return nil
+			}
+			res, err := cmdContext.loadResource(args[0])
+			if err != nil {
+				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load resource: %v\n", err)
+				return nil
+			}
+			items := cmdContext.getSubscriptionDestinations(api, res, namespaceSubscriptions)
+
+			switch output {
+			case "", "wide":
+				w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintf(w, "TRIGGER\tSERVICE\tRECIPIENT\tSOURCE\n")
+				for _, item := range items {
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Trigger, item.Service, item.Recipient, strings.Join(item.Sources, ", "))
+				}
+				_ = w.Flush()
+			case "name":
+				for _, item := range items {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "%s:%s:%s\n", item.Trigger, item.Service, item.Recipient)
+				}
+			default:
+				return misc.PrintFormatted(items, output, cmdContext.stdout)
+			}
+			return nil
+		},
+	}
+	addOutputFlags(&command, &output)
+	command.Flags().BoolVar(&namespaceSubscriptions, "namespace-subscriptions", false, "Include subscriptions of the namespace annotations")
+	return &command
+}
+
+// getSubscriptionDestinations returns effective destinations of the resource sorted by trigger, service and recipient
+func (c *commandContext) getSubscriptionDestinations(notificationsAPI api.API, res *unstructured.Unstructured, namespaceSubscriptions bool) []subscriptionDestination {
+	getNamespace := func() metav1.Object {
+		namespaceName := res.GetNamespace()
+		if namespaceName == "" {
+			namespaceName = c.namespace
+		}
+		ns, err := c.k8sClient.CoreV1().Namespaces().Get(context.Background(), namespaceName, metav1.GetOptions{})
+		if err != nil {
+			return nil
+		}
+		return ns
+	}
+
+	sources := controller.GetDestinationSources(res, res.Object, notificationsAPI, namespaceSubscriptions, getNamespace)
+	var items []subscriptionDestination
+	for _, item := range controller.GetDestinationsWithSources(notificationsAPI.GetConfig(), sources) {
+		items = append(items, subscriptionDestination{
+			Trigger:   item.Trigger,
+			Service:   item.Destination.Service,
+			Recipient: item.Destination.Recipient,
+			Sources:   item.Sources,
+		})
+	}
+	return items
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+)
+
+func TestSubscriptionAddAndRemove(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, map[string]string{}, newTestResource("guestbook"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	getAnnotations := func() map[string]string {
+		res, err := ctx.dynamicClient.Resource(ctx.resource).Namespace("default").Get(context.Background(), "guestbook", metav1.GetOptions{})
+		assert.NoError(t, err)
+		return res.GetAnnotations()
+	}
+	key := subscriptions.SubscribeAnnotationKey("my-trigger", "slack")
+
+	command := newSubscriptionAddCommand(ctx)
+	assert.NoError(t, command.Flags().Set("trigger", "my-trigger"))
+	err = command.RunE(command, []string{"guestbook", "slack:channel1", "slack:channel2"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, "channel1;channel2", getAnnotations()[key])
+
+	command = newSubscriptionRemoveCommand(ctx)
+	assert.NoError(t, command.Flags().Set("trigger", "my-trigger"))
+	err = command.RunE(command, []string{"guestbook", "slack:channel1"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, "channel2", getAnnotations()[key])
+
+	err = command.RunE(command, []string{"guestbook", "slack:channel2"})
+	assert.NoError(t, err)
+	assert.NotContains(t, getAnnotations(), key)
+}
+
+func TestSubscriptionAdd_File(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, map[string]string{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	data, err := yaml.Marshal(newTestResource("guestbook"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	file, err := ioutil.TempFile("", "*-app.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	_, err = file.Write(data)
+	assert.NoError(t, err)
+	_ = file.Close()
+
+	command := newSubscriptionAddCommand(ctx)
+	err = command.RunE(command, []string{file.Name(), "matrix:@ops:example.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+
+	data, err = ioutil.ReadFile(file.Name())
+	if !assert.NoError(t, err) {
+		return
+	}
+	var res unstructured.Unstructured
+	assert.NoError(t, yaml.Unmarshal(data, &res))
+	assert.Equal(t, "@ops:example.com", res.GetAnnotations()[subscriptions.SubscribeAnnotationKey("", "matrix")])
+}
+
+func TestSubscriptionList(t *testing.T) {
+	cmData := map[string]string{
+		"defaultTriggers": `[on-created]`,
+		"subscriptions": `
+- recipients: [slack:ops, group:platform]
+  triggers: [on-deleted]`,
+		"recipientGroups": `
+platform: [email:platform@example.com]`,
+	}
+	app := newTestResource("guestbook")
+	app.SetAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("on-deleted", "slack"): "ops",
+		subscriptions.SubscribeAnnotationKey("", "email"):           "dev@example.com",
+	})
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, app)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newSubscriptionListCommand(ctx)
+	err = command.RunE(command, []string{"guestbook"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, `TRIGGER     SERVICE  RECIPIENT             SOURCE
+on-created  email    dev@example.com       annotation notifications.argoproj.io/subscribe.email (default triggers)
+on-deleted  email    platform@example.com  default subscription via group platform
+on-deleted  slack    ops                   annotation notifications.argoproj.io/subscribe.on-deleted.slack, default subscription
+`, stdout.String())
+	// the namespace is not needed without namespace selectors and namespace subscriptions
+	assert.Empty(t, ctx.k8sClient.(*fake.Clientset).Actions())
+}
@@ -3,12 +3,14 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
 	"github.com/argoproj/notifications-engine/pkg/services"
+	"github.com/argoproj/notifications-engine/pkg/templatetest"
 	"github.com/argoproj/notifications-engine/pkg/util/misc"
 )
 
@@ -22,6 +24,8 @@ func newTemplateCommand(cmdContext *commandContext) *cobra.Command {
 	}
 	command.AddCommand(newTemplateNotifyCommand(cmdContext))
 	command.AddCommand(newTemplateGetCommand(cmdContext))
+	command.AddCommand(newTemplateTestCommand(cmdContext))
+	command.AddCommand(newTemplateServeCommand(cmdContext))
 
 	return &command
 }
@@ -134,3 +138,89 @@ func newTemplateGetCommand(cmdContext *commandContext) *cobra.Command {
 	addOutputFlags(&command, &output)
 	return &command
 }
+
+func newTemplateTestCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		update bool
+	)
+	var command = cobra.Command{
+		Use: "test PATH",
+		Example: fmt.Sprintf(`
+# Render test cases in the directory and compare notifications with the golden files
+%s template test ./testdata --config-map ./my-config-map.yaml
+
+# Regenerate the golden files
+%s template test ./testdata --config-map ./my-config-map.yaml --update
+`, cmdContext.cliName, cmdContext.cliName),
+		Short: "Renders templates for the test case resources and compares the notifications with the golden files",
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected one argument, got %d", len(args))
+			}
+			testCases, err := templatetest.LoadTestCases(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to load test cases: %v", err)
+			}
+			api, err := cmdContext.getAPI()
+			if err != nil {
+				return fmt.Errorf("failed to get api: %v", err)
+			}
+
+			failed := 0
+			for _, testCase := range testCases {
+				res, err := templatetest.Run(api, testCase, update)
+				if err != nil {
+					res.Errors = append(res.Errors, err.Error())
+				}
+				switch {
+				case !res.Passed():
+					failed++
+					_, _ = fmt.Fprintf(cmdContext.stdout, "FAIL %s\n", res.Name)
+					for _, err := range res.Errors {
+						_, _ = fmt.Fprintf(cmdContext.stdout, "    %s\n", err)
+					}
+					if res.Diff != "" {
+						_, _ = fmt.Fprintln(cmdContext.stdout, res.Diff)
+					}
+				case res.Updated:
+					_, _ = fmt.Fprintf(cmdContext.stdout, "UPDATED %s\n", res.Name)
+				default:
+					_, _ = fmt.Fprintf(cmdContext.stdout, "PASS %s\n", res.Name)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d test cases failed", failed, len(testCases))
+			}
+			return nil
+		},
+	}
+	command.Flags().BoolVar(&update, "update", false, "Regenerate the golden files")
+	return &command
+}
+
+func newTemplateServeCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		address string
+	)
+	var command = cobra.Command{
+		Use: "serve [RESOURCES_DIR]",
+		Example: fmt.Sprintf(`
+# Start the template playground for resource files of the current directory
+%s template serve --config-map ./my-config-map.yaml
+
+# Start the template playground on the custom address
+%s template serve ./testdata --config-map ./my-config-map.yaml --address localhost:9090
+`, cmdContext.cliName, cmdContext.cliName),
+		Short: "Starts a local web UI that live-renders templates against resource files",
+		RunE: func(c *cobra.Command, args []string) error {
+			resourcesDir := "."
+			if len(args) > 0 {
+				resourcesDir = args[0]
+			}
+			_, _ = fmt.Fprintf(cmdContext.stdout, "Template playground is available at http://%s\n", address)
+			return http.ListenAndServe(address, newPlaygroundHandler(cmdContext, resourcesDir))
+		},
+	}
+	command.Flags().StringVar(&address, "address", "localhost:8080", "Address the playground listens on")
+	return &command
+}
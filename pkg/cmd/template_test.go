@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,3 +53,83 @@ func TestTemplateGet(t *testing.T) {
 	assert.Contains(t, stdout.String(), "my-template1")
 	assert.Contains(t, stdout.String(), "my-template2")
 }
+
+func TestTemplateTest(t *testing.T) {
+	cmData := map[string]string{
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+	}
+	dir, err := ioutil.TempDir("", "testdata")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	data, err := yaml.Marshal(newTestResource("guestbook"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "guestbook.json"), data, 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "my-template.yaml"), []byte(`
+resource: guestbook.json
+template: my-template
+services: [slack]`), 0644))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTemplateTestCommand(ctx)
+	err = command.RunE(command, []string{dir})
+	assert.Error(t, err)
+	assert.Contains(t, stdout.String(), "FAIL my-template")
+
+	stdout.Reset()
+	assert.NoError(t, command.Flags().Set("update", "true"))
+	err = command.RunE(command, []string{dir})
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "UPDATED my-template")
+
+	stdout.Reset()
+	assert.NoError(t, command.Flags().Set("update", "false"))
+	err = command.RunE(command, []string{dir})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Equal(t, "PASS my-template\n", stdout.String())
+}
+
+func TestTemplateTest_InvalidConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdata")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "my-template.yaml"), []byte(`
+resource: guestbook.json
+template: my-template
+services: [slack]`), 0644))
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, map[string]string{
+		"template.my-template": `
+message: hello {{.app.metadata.name`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTemplateTestCommand(ctx)
+	err = command.RunE(command, []string{dir})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to get api")
+	}
+}
@@ -47,6 +47,9 @@ func NewToolsCommand(name string, cliName string, resource schema.GroupVersionRe
 
 	command.AddCommand(newTriggerCommand(&cmdContext))
 	command.AddCommand(newTemplateCommand(&cmdContext))
+	command.AddCommand(newSubscriptionCommand(&cmdContext))
+	command.AddCommand(newExplainCommand(&cmdContext))
+	command.AddCommand(newStateCommand(&cmdContext))
 
 	command.PersistentFlags().StringVar(&cmdContext.configMapPath,
 		"config-map", "", fmt.Sprintf("%s.yaml file path", settings.ConfigMapName))
@@ -3,9 +3,11 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"text/tabwriter"
 
+	"github.com/argoproj/notifications-engine/pkg/api"
 	"github.com/argoproj/notifications-engine/pkg/triggers"
 	"github.com/argoproj/notifications-engine/pkg/util/misc"
 
@@ -22,63 +24,140 @@ func newTriggerCommand(cmdContext *commandContext) *cobra.Command {
 	}
 	command.AddCommand(newTriggerRunCommand(cmdContext))
 	command.AddCommand(newTriggerGetCommand(cmdContext))
+	command.AddCommand(newTriggerTestCommand(cmdContext))
 
 	return &command
 }
 
+// conditionResult holds the result of the trigger condition evaluated against the resource
+type conditionResult struct {
+	Resource  string `json:"resource"`
+	Trigger   string `json:"trigger"`
+	Condition string `json:"condition"`
+	Triggered bool   `json:"triggered"`
+	Error     string `json:"error,omitempty"`
+}
+
 func newTriggerRunCommand(cmdContext *commandContext) *cobra.Command {
+	var (
+		selector      string
+		allNamespaces bool
+		allTriggers   bool
+		output        string
+	)
 	var command = cobra.Command{
-		Use:   "run NAME RESOURCE_NAME",
-		Short: "Evaluates specified trigger condition and prints the result",
+		Use:   "run [NAME] [RESOURCE_NAME|PATH]",
+		Short: "Evaluates specified trigger conditions against one or many resources and prints the results",
 		Example: fmt.Sprintf(`
 # Execute trigger configured in 'argocd-notification-cm' ConfigMap
 %s trigger run on-sync-status-unknown ./sample-app.yaml
 
 # Execute trigger using my-config-map.yaml instead of '%s' ConfigMap
 %s trigger run on-sync-status-unknown ./sample-app.yaml \
-    --config-map ./my-config-map.yaml`, cmdContext.cliName, cmdContext.ConfigMapName, cmdContext.cliName),
+    --config-map ./my-config-map.yaml
+
+# Execute all triggers against resources in the multi-document file or the directory
+%s trigger run --all-triggers ./manifests
+
+# Execute trigger against live resources with the matching labels in all namespaces
+%s trigger run on-sync-status-unknown -l env=prod --all-namespaces -o json`,
+			cmdContext.cliName, cmdContext.ConfigMapName, cmdContext.cliName, cmdContext.cliName, cmdContext.cliName),
 		RunE: func(c *cobra.Command, args []string) error {
-			if len(args) < 2 {
-				return fmt.Errorf("expected two arguments, got %d", len(args))
+			var names []string
+			if !allTriggers {
+				if len(args) == 0 {
+					return errors.New("expected trigger name or --all-triggers flag")
+				}
+				names, args = args[:1], args[1:]
 			}
-			name := args[0]
-			resourceName := args[1]
+			resourceName := ""
+			if len(args) > 1 {
+				return fmt.Errorf("expected at most one resource name, got %d", len(args))
+			} else if len(args) == 1 {
+				resourceName = args[0]
+			} else if selector == "" && !allNamespaces {
+				return errors.New("expected resource name, path, --selector or --all-namespaces flag")
+			}
+
 			api, err := cmdContext.getAPI()
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to get api: %v\n", err)
 				return nil
 			}
-			_, ok := api.GetConfig().Triggers[name]
-			if !ok {
-				var names []string
+			if allTriggers {
 				for name := range api.GetConfig().Triggers {
 					names = append(names, name)
 				}
-				_, _ = fmt.Fprintf(cmdContext.stderr,
-					"trigger with name '%s' does not exist (found %s)\n", name, strings.Join(names, ", "))
-				return nil
+				sort.Strings(names)
+			}
+			for _, name := range names {
+				if _, ok := api.GetConfig().Triggers[name]; !ok {
+					var existing []string
+					for name := range api.GetConfig().Triggers {
+						existing = append(existing, name)
+					}
+					_, _ = fmt.Fprintf(cmdContext.stderr,
+						"trigger with name '%s' does not exist (found %s)\n", name, strings.Join(existing, ", "))
+					return nil
+				}
 			}
-			r, err := cmdContext.loadResource(resourceName)
+			resources, err := cmdContext.loadResources(resourceName, selector, allNamespaces)
 			if err != nil {
 				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to load resource: %v\n", err)
 				return nil
 			}
 
-			res, err := api.RunTrigger(name, r.Object)
-			if err != nil {
-				_, _ = fmt.Fprintf(cmdContext.stderr, "failed to execute trigger %s: %v\n", name, err)
-				return nil
+			var results []conditionResult
+			for _, r := range resources {
+				resource := r.GetName()
+				if r.GetNamespace() != "" {
+					resource = r.GetNamespace() + "/" + resource
+				}
+				for _, name := range names {
+					res, err := api.RunTrigger(name, r.Object)
+					if err != nil {
+						_, _ = fmt.Fprintf(cmdContext.stderr, "failed to execute trigger %s: %v\n", name, err)
+						return nil
+					}
+					conditions := api.GetConfig().Triggers[name]
+					for i := range res {
+						result := conditionResult{Resource: resource, Trigger: name, Condition: res[i].Key, Triggered: res[i].Triggered}
+						if i < len(conditions) {
+							result.Condition = conditions[i].When
+							if result.Condition == "" {
+								result.Condition = "trigger: " + conditions[i].Trigger
+							}
+						}
+						if res[i].Error != nil {
+							result.Error = res[i].Error.Error()
+						}
+						results = append(results, result)
+					}
+				}
 			}
-			w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintf(w, "CONDITION\tRESULT\n")
-			for i := range res {
-				_, _ = fmt.Fprintf(w, "%s\t%v\n", api.GetConfig().Triggers[name][i].When, res[i].Triggered)
+
+			switch output {
+			case "", "wide":
+				w := tabwriter.NewWriter(cmdContext.stdout, 5, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintf(w, "RESOURCE\tTRIGGER\tCONDITION\tRESULT\n")
+				for _, result := range results {
+					triggered := fmt.Sprintf("%v", result.Triggered)
+					if result.Error != "" {
+						triggered = fmt.Sprintf("%s (error: %s)", triggered, result.Error)
+					}
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Resource, result.Trigger, result.Condition, triggered)
+				}
+				_ = w.Flush()
+			default:
+				return misc.PrintFormatted(results, output, cmdContext.stdout)
 			}
-			_ = w.Flush()
 			return nil
 		},
 	}
-
+	command.Flags().StringVarP(&selector, "selector", "l", "", "Label selector of the live resources")
+	command.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Evaluate live resources in all namespaces")
+	command.Flags().BoolVar(&allTriggers, "all-triggers", false, "Evaluate all configured triggers")
+	command.Flags().StringVarP(&output, "output", "o", "wide", "Output format. One of:json|yaml|wide")
 	return &command
 }
 
@@ -139,3 +218,50 @@ func newTriggerGetCommand(cmdContext *commandContext) *cobra.Command {
 	addOutputFlags(&command, &output)
 	return &command
 }
+
+func newTriggerTestCommand(cmdContext *commandContext) *cobra.Command {
+	var command = cobra.Command{
+		Use: "test [NAME]",
+		Example: fmt.Sprintf(`
+# Execute test cases of all triggers configured in '%s' ConfigMap
+%s trigger test
+
+# Execute test cases of the on-sync-failed trigger using my-config-map.yaml
+%s trigger test on-sync-failed --config-map ./my-config-map.yaml
+`, cmdContext.ConfigMapName, cmdContext.cliName, cmdContext.cliName),
+		Short: "Executes test cases embedded in the trigger definitions",
+		RunE: func(c *cobra.Command, args []string) error {
+			var name string
+			if len(args) == 1 {
+				name = args[0]
+			}
+			notificationsAPI, err := cmdContext.getAPI()
+			if err != nil {
+				return fmt.Errorf("failed to get api: %v", err)
+			}
+			tests := notificationsAPI.GetConfig().TriggerTests
+			if name != "" {
+				if _, ok := notificationsAPI.GetConfig().Triggers[name]; !ok {
+					return fmt.Errorf("trigger '%s' is not configured", name)
+				}
+				tests = map[string][]triggers.TestCase{name: tests[name]}
+			}
+
+			results := api.RunTriggerTests(notificationsAPI, tests)
+			failed := 0
+			for _, res := range results {
+				if res.Error != nil {
+					failed++
+					_, _ = fmt.Fprintf(cmdContext.stdout, "FAIL %s/%s\n    %v\n", res.Trigger, res.Test, res.Error)
+				} else {
+					_, _ = fmt.Fprintf(cmdContext.stdout, "PASS %s/%s\n", res.Trigger, res.Test)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d test cases failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+	return &command
+}
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -107,6 +109,144 @@ message: hello {{.app.metadata.name}}`,
 	assert.Contains(t, stdout.String(), "true")
 }
 
+func TestTriggerTest(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+conditions:
+- when: app.metadata.name == 'guestbook'
+  send: [my-template]
+tests:
+- name: guestbook
+  object: {metadata: {name: guestbook}}
+  triggered: ['[0]']
+- name: other
+  object: {metadata: {name: other}}
+  triggered: ['[0]']`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerTestCommand(ctx)
+	err = command.RunE(command, []string{"my-trigger"})
+	assert.EqualError(t, err, "1 of 2 test cases failed")
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "PASS my-trigger/guestbook")
+	assert.Contains(t, stdout.String(), "FAIL my-trigger/other")
+}
+
+func TestTriggerTest_InvalidConfig(t *testing.T) {
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, map[string]string{
+		"trigger.my-trigger": `
+- when: app.metadata.name ==
+  send: [my-template]`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerTestCommand(ctx)
+	err = command.RunE(command, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to get api")
+	}
+}
+
+func TestTriggerRun_AllTriggersInDirectory(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger1": `
+- when: app.metadata.name == 'guestbook'
+  send: [my-template]`,
+		"trigger.my-trigger2": `
+- when: app.metadata.name == 'other'
+  send: [my-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+	}
+
+	dir, err := ioutil.TempDir("", "resources")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	var manifests []string
+	for _, name := range []string{"guestbook", "other"} {
+		data, err := yaml.Marshal(newTestResource(name))
+		if !assert.NoError(t, err) {
+			return
+		}
+		manifests = append(manifests, string(data))
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "apps.yaml"), []byte(strings.Join(manifests, "---\n")), 0644)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerRunCommand(ctx)
+	assert.NoError(t, command.Flags().Set("all-triggers", "true"))
+	assert.NoError(t, command.Flags().Set("output", "json"))
+	err = command.RunE(command, []string{dir})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+
+	var results []conditionResult
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &results))
+	assert.Equal(t, []conditionResult{
+		{Resource: "default/guestbook", Trigger: "my-trigger1", Condition: "app.metadata.name == 'guestbook'", Triggered: true},
+		{Resource: "default/guestbook", Trigger: "my-trigger2", Condition: "app.metadata.name == 'other'"},
+		{Resource: "default/other", Trigger: "my-trigger1", Condition: "app.metadata.name == 'guestbook'"},
+		{Resource: "default/other", Trigger: "my-trigger2", Condition: "app.metadata.name == 'other'", Triggered: true},
+	}, results)
+}
+
+func TestTriggerRun_Selector(t *testing.T) {
+	cmData := map[string]string{
+		"trigger.my-trigger": `
+- when: 'true'
+  send: [my-template]`,
+		"template.my-template": `
+message: hello {{.app.metadata.name}}`,
+	}
+	prod := newTestResource("prod-app")
+	prod.SetLabels(map[string]string{"env": "prod"})
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	ctx, closer, err := newTestContext(&stdout, &stderr, cmData, prod, newTestResource("dev-app"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closer()
+
+	command := newTriggerRunCommand(ctx)
+	assert.NoError(t, command.Flags().Set("selector", "env=prod"))
+	err = command.RunE(command, []string{"my-trigger"})
+	assert.NoError(t, err)
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "default/prod-app")
+	assert.NotContains(t, stdout.String(), "dev-app")
+}
+
 func TestTriggerGet(t *testing.T) {
 	cmData := map[string]string{
 		"trigger.my-trigger1": `
@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
@@ -21,6 +26,8 @@ import (
 	"github.com/argoproj/notifications-engine/pkg/api"
 	"github.com/argoproj/notifications-engine/pkg/services"
 	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+	"github.com/argoproj/notifications-engine/pkg/triggers"
+	"github.com/argoproj/notifications-engine/pkg/util/misc"
 )
 
 // NotificationDelivery represents a notification that was delivered
@@ -31,6 +38,10 @@ type NotificationDelivery struct {
 	Destination services.Destination
 	// AlreadyNotified indicates that this notification was already delivered in a previous iteration
 	AlreadyNotified bool
+	// DryRun indicates that the notification was rendered but not delivered because the controller runs in the dry-run mode
+	DryRun bool
+	// Notification is the rendered notification, set only in the dry-run mode
+	Notification *services.Notification
 }
 
 // NotificationEventSequence represents a sequence of events that occurred while
@@ -90,6 +101,42 @@ func WithSkipProcessing(f func(obj v1.Object) (bool, string)) Opts {
 	}
 }
 
+// WithNamespaceInformer enables subscriptions declared in the annotations of the resource namespace and default
+// subscriptions with the namespace selector. The informer is not started by the controller. Resources are reprocessed
+// when the namespace labels or annotations change.
+func WithNamespaceInformer(informer cache.SharedIndexInformer) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.namespaceInformer = informer
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				ctrl.enqueueNamespaceResources(old, new)
+			},
+		})
+	}
+}
+
+// WithDryRun enables the dry-run mode. The controller evaluates triggers and renders notifications but does not call
+// notification services and does not update the notifications state annotation. Rendered notifications are reported
+// using metrics and the event callback, and written to the optional output using the console service. The state of
+// the rendered notifications is kept in memory, so the same notification is rendered once, and removed when the
+// resource is deleted.
+func WithDryRun(output io.Writer) Opts {
+	return func(ctrl *notificationController) {
+		ctrl.dryRun = true
+		ctrl.dryRunOutput = output
+		ctrl.dryRunStates = map[string]NotificationsState{}
+		if ctrl.informer != nil {
+			ctrl.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+				DeleteFunc: func(obj interface{}) {
+					if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+						ctrl.deleteDryRunState(key)
+					}
+				},
+			})
+		}
+	}
+}
+
 // WithEventCallback registers a callback to invoke when an object has been
 // processed for notifications.
 func WithEventCallback(f func(eventSequence NotificationEventSequence)) Opts {
@@ -128,13 +175,7 @@ func NewController(
 		queue:           queue,
 		metricsRegistry: NewMetricsRegistry(""),
 		apiFactory:      apiFactory,
-		toUnstructured: func(obj v1.Object) (*unstructured.Unstructured, error) {
-			res, ok := obj.(*unstructured.Unstructured)
-			if !ok {
-				return nil, fmt.Errorf("Object must be *unstructured.Unstructured but was: %v", res)
-			}
-			return res, nil
-		},
+		toUnstructured:  defaultToUnstructured,
 	}
 	for i := range opts {
 		opts[i](ctrl)
@@ -142,6 +183,14 @@ func NewController(
 	return ctrl
 }
 
+func defaultToUnstructured(obj v1.Object) (*unstructured.Unstructured, error) {
+	res, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("Object must be *unstructured.Unstructured but was: %v", res)
+	}
+	return res, nil
+}
+
 type notificationController struct {
 	client            dynamic.NamespaceableResourceInterface
 	informer          cache.SharedIndexInformer
@@ -152,12 +201,25 @@ type notificationController struct {
 	alterDestinations func(obj v1.Object, destinations services.Destinations, cfg api.Config) services.Destinations
 	toUnstructured    func(obj v1.Object) (*unstructured.Unstructured, error)
 	eventCallback     func(eventSequence NotificationEventSequence)
+	namespaceInformer cache.SharedIndexInformer
+	dryRun            bool
+	dryRunOutput      io.Writer
+	dryRunStates      map[string]NotificationsState
+	dryRunStatesLock  sync.Mutex
+	initialKeys       map[string]bool
+	initialKeysLock   sync.Mutex
 }
 
 func (c *notificationController) Run(threadiness int, stopCh <-chan struct{}) {
 	defer runtimeutil.HandleCrash()
 	defer c.queue.ShutDown()
 
+	if !cache.WaitForCacheSync(stopCh, c.informer.HasSynced) {
+		log.Warn("Controller has stopped before informer synced.")
+		return
+	}
+	c.setInitialKeys(c.informer.GetStore().ListKeys())
+
 	log.Warn("Controller is running.")
 	for i := 0; i < threadiness; i++ {
 		go wait.Until(func() {
@@ -170,21 +232,26 @@ func (c *notificationController) Run(threadiness int, stopCh <-chan struct{}) {
 }
 
 func (c *notificationController) processResource(resource v1.Object, logEntry *log.Entry, eventSequence *NotificationEventSequence) (map[string]string, error) {
-	notificationsState := NewStateFromRes(resource)
+	notificationsState := c.getState(resource)
 	api, err := c.apiFactory.GetAPI()
 	if err != nil {
 		return nil, err
 	}
 
-	destinations := c.getDestinations(resource, api.GetConfig())
+	un, err := c.toUnstructured(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations := c.getDestinations(resource, un.Object, api)
 	if len(destinations) == 0 {
 		return resource.GetAnnotations(), nil
 	}
-
-	un, err := c.toUnstructured(resource)
+	key, err := cache.MetaNamespaceKeyFunc(resource)
 	if err != nil {
 		return nil, err
 	}
+	initialSync := c.isInitialKey(key)
 
 	for trigger, destinations := range destinations {
 		res, err := api.RunTrigger(trigger, un.Object)
@@ -196,6 +263,16 @@ func (c *notificationController) processResource(resource v1.Object, logEntry *l
 
 		for _, cr := range res {
 			c.metricsRegistry.IncTriggerEvaluationsCounter(trigger, cr.Triggered)
+			if cr.Error != nil {
+				c.metricsRegistry.IncTriggerEvaluationErrorsCounter(trigger)
+				if api.GetConfig().StrictTriggers {
+					eventSequence.addWarning(fmt.Errorf("failed to evaluate condition '%s' of trigger %s: %v", cr.Key, trigger, cr.Error))
+				}
+			}
+
+			for _, to := range destinations {
+				notificationsState.Migrate(trigger, cr, to)
+			}
 
 			if !cr.Triggered {
 				for _, to := range destinations {
@@ -205,6 +282,16 @@ func (c *notificationController) processResource(resource v1.Object, logEntry *l
 			}
 
 			for _, to := range destinations {
+				templates := DestinationTemplates(api.GetConfig(), cr, to)
+				if len(templates) == 0 {
+					logEntry.Warnf("Notification about condition '%s.%s' to '%v' is skipped: no templates configured for the service", trigger, cr.Key, to)
+					continue
+				}
+				if to.Options.IsSilenced(time.Now()) {
+					logEntry.Infof("Notification about condition '%s.%s' to '%v' is silenced", trigger, cr.Key, to)
+					notificationsState.SetAlreadyNotified(trigger, cr, to, true)
+					continue
+				}
 				if changed := notificationsState.SetAlreadyNotified(trigger, cr, to, true); !changed {
 					logEntry.Infof("Notification about condition '%s.%s' already sent to '%v'", trigger, cr.Key, to)
 					eventSequence.addDelivered(NotificationDelivery{
@@ -212,21 +299,35 @@ func (c *notificationController) processResource(resource v1.Object, logEntry *l
 						Destination:     to,
 						AlreadyNotified: true,
 					})
+				} else if initialSync && api.GetConfig().SuppressInitialSync[trigger] {
+					logEntry.Infof("Notification about condition '%s.%s' to '%v' is suppressed on initial sync", trigger, cr.Key, to)
 				} else {
 					logEntry.Infof("Sending notification about condition '%s.%s' to '%v'", trigger, cr.Key, to)
-					if err := api.Send(un.Object, cr.Templates, to); err != nil {
+					var notification *services.Notification
+					if c.dryRun {
+						notification, err = c.render(api, un.Object, templates, to)
+					} else {
+						err = api.Send(un.Object, templates, to)
+					}
+					if err != nil {
 						logEntry.Errorf("Failed to notify recipient %s defined in resource %s/%s: %v",
 							to, resource.GetNamespace(), resource.GetName(), err)
 						notificationsState.SetAlreadyNotified(trigger, cr, to, false)
-						c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, false)
+						c.incDeliveriesCounter(trigger, to.Service, false)
 						eventSequence.addError(fmt.Errorf("failed to deliver notification %s to %s: %v", trigger, to, err))
 					} else {
-						logEntry.Debugf("Notification %s was sent", to.Recipient)
-						c.metricsRegistry.IncDeliveriesCounter(trigger, to.Service, true)
+						if c.dryRun {
+							logEntry.Infof("Notification %s was rendered but not sent in the dry-run mode", to.Recipient)
+						} else {
+							logEntry.Debugf("Notification %s was sent", to.Recipient)
+						}
+						c.incDeliveriesCounter(trigger, to.Service, true)
 						eventSequence.addDelivered(NotificationDelivery{
 							Trigger:         trigger,
 							Destination:     to,
 							AlreadyNotified: false,
+							DryRun:          c.dryRun,
+							Notification:    notification,
 						})
 					}
 				}
@@ -234,16 +335,290 @@ func (c *notificationController) processResource(resource v1.Object, logEntry *l
 		}
 	}
 
+	if c.dryRun {
+		c.setDryRunState(resource, notificationsState)
+		return resource.GetAnnotations(), nil
+	}
 	return notificationsState.Persist(resource)
 }
 
-func (c *notificationController) getDestinations(resource v1.Object, cfg api.Config) services.Destinations {
-	res := cfg.GetGlobalDestinations(resource.GetLabels())
-	res.Merge(subscriptions.NewAnnotations(resource.GetAnnotations()).GetDestinations(cfg.DefaultTriggers, cfg.ServiceDefaultTriggers))
+// DestinationTemplates returns templates of the triggered condition for the destination, subscription templates take
+// precedence over the condition templates
+func DestinationTemplates(cfg api.Config, cr triggers.ConditionResult, to services.Destination) []string {
+	if to.Options != nil && len(to.Options.Templates) > 0 {
+		return to.Options.Templates
+	}
+	return cr.GetTemplates(to.Service, cfg.ServiceTypes[to.Service])
+}
+
+// setInitialKeys records keys of the resources that existed when the controller started
+func (c *notificationController) setInitialKeys(keys []string) {
+	c.initialKeysLock.Lock()
+	defer c.initialKeysLock.Unlock()
+	c.initialKeys = map[string]bool{}
+	for _, key := range keys {
+		c.initialKeys[key] = true
+	}
+}
+
+func (c *notificationController) isInitialKey(key string) bool {
+	c.initialKeysLock.Lock()
+	defer c.initialKeysLock.Unlock()
+	return c.initialKeys[key]
+}
+
+// completeInitialSync marks that the resource that existed when the controller started has been processed, skipped or
+// failed to process
+func (c *notificationController) completeInitialSync(key string) {
+	c.initialKeysLock.Lock()
+	defer c.initialKeysLock.Unlock()
+	delete(c.initialKeys, key)
+}
+
+// getState returns the notifications state of the resource. In the dry-run mode the state is kept in memory.
+func (c *notificationController) getState(resource v1.Object) NotificationsState {
+	if c.dryRun {
+		c.dryRunStatesLock.Lock()
+		defer c.dryRunStatesLock.Unlock()
+		key, _ := cache.MetaNamespaceKeyFunc(resource)
+		if state, ok := c.dryRunStates[key]; ok {
+			res := NotificationsState{}
+			for k, v := range state {
+				res[k] = v
+			}
+			return res
+		}
+	}
+	return NewStateFromRes(resource)
+}
+
+func (c *notificationController) setDryRunState(resource v1.Object, state NotificationsState) {
+	c.dryRunStatesLock.Lock()
+	defer c.dryRunStatesLock.Unlock()
+	state.truncate(notifiedHistoryMaxSize)
+	key, _ := cache.MetaNamespaceKeyFunc(resource)
+	c.dryRunStates[key] = state
+}
+
+// deleteDryRunState removes the in-memory state of the deleted resource
+func (c *notificationController) deleteDryRunState(key string) {
+	c.dryRunStatesLock.Lock()
+	defer c.dryRunStatesLock.Unlock()
+	delete(c.dryRunStates, key)
+}
+
+// render renders the notification in the dry-run mode and writes it to the dry-run output
+func (c *notificationController) render(api api.API, obj map[string]interface{}, templates []string, to services.Destination) (*services.Notification, error) {
+	notification, err := api.Render(obj, templates, to)
+	if err != nil {
+		return nil, err
+	}
+	if c.dryRunOutput != nil {
+		if err := services.NewConsoleService(c.dryRunOutput).Send(*notification, to); err != nil {
+			return nil, err
+		}
+	}
+	return notification, nil
+}
+
+func (c *notificationController) incDeliveriesCounter(trigger string, service string, succeeded bool) {
+	if c.dryRun {
+		c.metricsRegistry.IncDryRunDeliveriesCounter(trigger, service, succeeded)
+	} else {
+		c.metricsRegistry.IncDeliveriesCounter(trigger, service, succeeded)
+	}
+}
+
+func (c *notificationController) getDestinations(resource v1.Object, obj map[string]interface{}, api api.API) services.Destinations {
+	cfg := api.GetConfig()
+	res := services.Destinations{}
+	for _, source := range c.getDestinationSources(resource, obj, api) {
+		res.Merge(source.Destinations)
+	}
 	if c.alterDestinations != nil {
 		res = c.alterDestinations(resource, res, cfg)
 	}
-	return res.Dedup()
+	return cfg.ExpandRecipientGroups(res).Dedup()
+}
+
+// DestinationsSource holds destinations that come from the same subscription
+type DestinationsSource struct {
+	// Name is the kind of the subscription: default subscription, namespace annotation or annotation
+	Name string
+	// Annotation is the key of the subscription annotation, empty for default subscriptions
+	Annotation string
+	// DefaultTriggers indicates that the annotation does not specify the trigger and default triggers are used
+	DefaultTriggers bool
+	// Destinations holds destinations of the subscription
+	Destinations services.Destinations
+}
+
+func (c *notificationController) getDestinationSources(resource v1.Object, obj map[string]interface{}, api api.API) []DestinationsSource {
+	return GetDestinationSources(resource, obj, api, c.namespaceInformer != nil, func() v1.Object {
+		return c.getNamespace(resource.GetNamespace())
+	})
+}
+
+// GetDestinationSources returns destinations of the default subscriptions, namespace annotations and resource annotations.
+// The getNamespace function is called only if namespace annotations are enabled or a default subscription selects namespaces.
+func GetDestinationSources(
+	resource v1.Object,
+	obj map[string]interface{},
+	notificationsAPI api.API,
+	namespaceAnnotations bool,
+	getNamespace func() v1.Object,
+) []DestinationsSource {
+	cfg := notificationsAPI.GetConfig()
+	var namespace v1.Object
+	if namespaceAnnotations || cfg.Subscriptions.HasNamespaceSelector() {
+		namespace = getNamespace()
+	}
+	var namespaceLabels map[string]string
+	if namespace != nil {
+		namespaceLabels = namespace.GetLabels()
+		if namespaceLabels == nil {
+			namespaceLabels = map[string]string{}
+		}
+	}
+	sources := []DestinationsSource{{Name: "default subscription", Destinations: notificationsAPI.GetGlobalDestinations(obj, namespaceLabels)}}
+	if namespaceAnnotations && namespace != nil {
+		sources = append(sources, annotationSources(cfg, "namespace annotation", namespace.GetAnnotations())...)
+	}
+	return append(sources, annotationSources(cfg, "annotation", resource.GetAnnotations())...)
+}
+
+// annotationSources returns destinations of each subscription annotation, destinations of the default triggers are
+// returned separately
+func annotationSources(cfg api.Config, name string, annotations map[string]string) []DestinationsSource {
+	var sources []DestinationsSource
+	misc.IterateStringKeyMap(annotations, func(key string) {
+		a := subscriptions.NewAnnotations(map[string]string{key: annotations[key]})
+		explicit := a.GetDestinations(nil, nil)
+		defaults := services.Destinations{}
+		for trigger, dests := range a.GetDestinations(cfg.DefaultTriggers, cfg.ServiceDefaultTriggers) {
+			for _, dest := range dests {
+				if !containsDestination(explicit[trigger], dest) {
+					defaults[trigger] = append(defaults[trigger], dest)
+				}
+			}
+		}
+		if len(explicit) > 0 {
+			sources = append(sources, DestinationsSource{Name: name, Annotation: key, Destinations: explicit})
+		}
+		if len(defaults) > 0 {
+			sources = append(sources, DestinationsSource{Name: name, Annotation: key, DefaultTriggers: true, Destinations: defaults})
+		}
+	})
+	return sources
+}
+
+// DestinationWithSources holds the effective destination of the trigger and names of the subscriptions it comes from
+type DestinationWithSources struct {
+	Trigger     string
+	Destination services.Destination
+	Sources     []string
+}
+
+// GetDestinationsWithSources expands recipient groups of the subscription destinations and returns the effective
+// destinations with names of the subscriptions they come from, sorted by trigger, service and recipient
+func GetDestinationsWithSources(cfg api.Config, sources []DestinationsSource) []DestinationWithSources {
+	byKey := map[string]*DestinationWithSources{}
+	for _, source := range sources {
+		for trigger, dests := range source.Destinations {
+			for _, dest := range dests {
+				name := sourceName(cfg, source, dest)
+				if dest.Service == api.RecipientGroupService {
+					name = fmt.Sprintf("%s via group %s", name, dest.Recipient)
+				}
+				for _, member := range cfg.ExpandRecipientGroups(services.Destinations{trigger: {dest}})[trigger] {
+					key := destinationKey(trigger, member)
+					item, ok := byKey[key]
+					if !ok {
+						item = &DestinationWithSources{Trigger: trigger, Destination: member}
+						byKey[key] = item
+					}
+					item.Sources = append(item.Sources, name)
+				}
+			}
+		}
+	}
+
+	var res []DestinationWithSources
+	for _, item := range byKey {
+		sort.Strings(item.Sources)
+		res = append(res, *item)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Trigger != res[j].Trigger {
+			return res[i].Trigger < res[j].Trigger
+		}
+		if res[i].Destination.Service != res[j].Destination.Service {
+			return res[i].Destination.Service < res[j].Destination.Service
+		}
+		return res[i].Destination.Recipient < res[j].Destination.Recipient
+	})
+	return res
+}
+
+// sourceName returns the name of the subscription and explains if the default triggers are used
+func sourceName(cfg api.Config, source DestinationsSource, dest services.Destination) string {
+	if source.Annotation == "" {
+		return source.Name
+	}
+	name := fmt.Sprintf("%s %s", source.Name, source.Annotation)
+	if !source.DefaultTriggers {
+		return name
+	}
+	if _, ok := cfg.ServiceDefaultTriggers[dest.Service]; ok {
+		return name + " (service default triggers)"
+	}
+	return name + " (default triggers)"
+}
+
+func containsDestination(dests []services.Destination, dest services.Destination) bool {
+	for _, item := range dests {
+		if item.Service == dest.Service && item.Recipient == dest.Recipient {
+			return true
+		}
+	}
+	return false
+}
+
+// getNamespace returns the namespace of the resource or nil if the namespace informer is not configured
+func (c *notificationController) getNamespace(name string) v1.Object {
+	if c.namespaceInformer == nil || name == "" {
+		return nil
+	}
+	obj, exists, err := c.namespaceInformer.GetStore().GetByKey(name)
+	if err != nil || !exists {
+		return nil
+	}
+	namespace, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	return namespace
+}
+
+// enqueueNamespaceResources adds resources of the namespace to the queue if the namespace labels or annotations have changed
+func (c *notificationController) enqueueNamespaceResources(old, new interface{}) {
+	oldNamespace, err := meta.Accessor(old)
+	if err != nil {
+		return
+	}
+	newNamespace, err := meta.Accessor(new)
+	if err != nil {
+		return
+	}
+	if mapsEqual(oldNamespace.GetLabels(), newNamespace.GetLabels()) && mapsEqual(oldNamespace.GetAnnotations(), newNamespace.GetAnnotations()) {
+		return
+	}
+	prefix := newNamespace.GetName() + "/"
+	for _, key := range c.informer.GetStore().ListKeys() {
+		if strings.HasPrefix(key, prefix) {
+			c.queue.Add(key)
+		}
+	}
 }
 
 func (c *notificationController) processQueueItem() (processNext bool) {
@@ -259,6 +634,8 @@ func (c *notificationController) processQueueItem() (processNext bool) {
 		}
 		c.queue.Done(key)
 	}()
+	// only the first processing after the controller start belongs to the initial sync, even if it is skipped or fails
+	defer c.completeInitialSync(key.(string))
 
 	eventSequence := NotificationEventSequence{Key: key.(string)}
 	defer func() {
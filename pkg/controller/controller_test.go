@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
@@ -73,6 +75,7 @@ func newController(t *testing.T, ctx context.Context, client dynamic.Interface,
 	}()
 	mockAPI := mocks.NewMockAPI(mockCtrl)
 	mockAPI.EXPECT().GetConfig().Return(api.Config{}).AnyTimes()
+	mockAPI.EXPECT().GetGlobalDestinations(gomock.Any(), gomock.Any()).Return(services.Destinations{}).AnyTimes()
 	resourceClient := client.Resource(testGVR)
 	informer := cache.NewSharedIndexInformer(
 		&cache.ListWatch{
@@ -127,6 +130,28 @@ func TestSendsNotificationIfTriggered(t *testing.T) {
 	assert.Equal(t, app.Object, receivedObj)
 }
 
+func TestSkipsDestinationWithoutTemplates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+
+	ctrl, api, err := newController(t, ctx, newFakeClient(app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{
+		Triggered:          true,
+		TemplatesByService: map[string][]string{"slack": {"slack-template"}},
+	}}, nil)
+
+	annotations, err := ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+
+	state := NewState(annotations[notifiedAnnotationKey])
+	assert.Empty(t, state)
+}
+
 func TestDoesNotSendNotificationIfAnnotationPresent(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	defer cancel()
@@ -348,3 +373,272 @@ func TestWithEventCallback(t *testing.T) {
 		})
 	}
 }
+
+func newNamespace(name string, labels map[string]string, annotations map[string]string) *unstructured.Unstructured {
+	ns := unstructured.Unstructured{}
+	ns.SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"})
+	ns.SetName(name)
+	ns.SetLabels(labels)
+	ns.SetAnnotations(annotations)
+	return &ns
+}
+
+func TestWithNamespaceInformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test")
+	namespaceInformer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, time.Minute, cache.Indexers{})
+	err := namespaceInformer.GetStore().Add(newNamespace(testNamespace, nil, map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	assert.NoError(t, err)
+
+	ctrl, api, err := newController(t, ctx, newFakeClient(app), WithNamespaceInformer(namespaceInformer))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil)
+
+	_, err = ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+}
+
+func TestGetDestinationSources_NamespaceIsFetchedOnlyIfNeeded(t *testing.T) {
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAPI := mocks.NewMockAPI(mockCtrl)
+	cfg := api.Config{}
+	mockAPI.EXPECT().GetConfig().DoAndReturn(func() api.Config { return cfg }).AnyTimes()
+	mockAPI.EXPECT().GetGlobalDestinations(gomock.Any(), gomock.Any()).Return(services.Destinations{}).AnyTimes()
+
+	fetched := 0
+	getNamespace := func() v1.Object {
+		fetched++
+		return newNamespace(testNamespace, map[string]string{"team": "a"}, nil)
+	}
+
+	sources := GetDestinationSources(app, app.Object, mockAPI, false, getNamespace)
+	assert.Equal(t, 0, fetched)
+	assert.Equal(t, []DestinationsSource{{Name: "default subscription", Destinations: services.Destinations{}}, {
+		Name:         "annotation",
+		Annotation:   subscriptions.SubscribeAnnotationKey("my-trigger", "mock"),
+		Destinations: services.Destinations{"my-trigger": {{Service: "mock", Recipient: "recipient"}}},
+	}}, sources)
+
+	selector, err := labels.Parse("team=a")
+	assert.NoError(t, err)
+	cfg.Subscriptions = subscriptions.DefaultSubscriptions{{Recipients: []string{"mock:recipient"}, NamespaceSelector: selector}}
+	GetDestinationSources(app, app.Object, mockAPI, false, getNamespace)
+	assert.Equal(t, 1, fetched)
+}
+
+func TestGetDestinationsWithSources(t *testing.T) {
+	cfg := api.Config{RecipientGroups: map[string][]string{"platform": {"mock:ops", "mock:dev"}}}
+	sources := []DestinationsSource{{
+		Name:         "default subscription",
+		Destinations: services.Destinations{"my-trigger": {{Service: api.RecipientGroupService, Recipient: "platform"}}},
+	}, {
+		Name:         "annotation",
+		Annotation:   "my-annotation",
+		Destinations: services.Destinations{"my-trigger": {{Service: "mock", Recipient: "ops"}}},
+	}}
+
+	assert.Equal(t, []DestinationWithSources{{
+		Trigger:     "my-trigger",
+		Destination: services.Destination{Service: "mock", Recipient: "dev"},
+		Sources:     []string{"default subscription via group platform"},
+	}, {
+		Trigger:     "my-trigger",
+		Destination: services.Destination{Service: "mock", Recipient: "ops"},
+		Sources:     []string{"annotation my-annotation", "default subscription via group platform"},
+	}}, GetDestinationsWithSources(cfg, sources))
+}
+
+func TestEnqueueNamespaceResources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, err := newController(t, ctx, newFakeClient())
+	assert.NoError(t, err)
+	assert.NoError(t, ctrl.informer.GetStore().Add(newResource("test")))
+
+	ns := newNamespace(testNamespace, map[string]string{"team": "a"}, nil)
+	ctrl.enqueueNamespaceResources(ns, ns)
+	assert.Equal(t, 0, ctrl.queue.Len())
+
+	ctrl.enqueueNamespaceResources(newNamespace("other", nil, nil), newNamespace("other", map[string]string{"team": "b"}, nil))
+	assert.Equal(t, 0, ctrl.queue.Len())
+
+	ctrl.enqueueNamespaceResources(ns, newNamespace(testNamespace, map[string]string{"team": "b"}, nil))
+	assert.Equal(t, 1, ctrl.queue.Len())
+}
+
+func TestDestinationOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+		"notifications.argoproj.io/subscriptions": `
+- trigger: [my-trigger]
+  destinations:
+  - service: mock
+    recipients: [silenced]
+  silence: {}
+- trigger: [my-trigger]
+  destinations:
+  - service: mock
+    recipients: [custom]
+  templates: [custom-template]`,
+	}))
+
+	ctrl, api, err := newController(t, ctx, newFakeClient(app))
+	assert.NoError(t, err)
+
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil)
+	api.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil)
+	api.EXPECT().Send(gomock.Any(), []string{"custom-template"}, gomock.Any()).Return(nil)
+
+	annotations, err := ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+	state := NewState(annotations[notifiedAnnotationKey])
+	assert.Len(t, state, 3)
+}
+
+func TestWithDryRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+
+	var output bytes.Buffer
+	ctrl, api, err := newController(t, ctx, newFakeClient(app), WithDryRun(&output))
+	assert.NoError(t, err)
+
+	dest := services.Destination{Service: "mock", Recipient: "recipient"}
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil).Times(2)
+	api.EXPECT().Render(gomock.Any(), []string{"test"}, dest).Return(&services.Notification{Message: "hello"}, nil).Times(1)
+
+	eventSequence := NotificationEventSequence{}
+	annotations, err := ctrl.processResource(app, logEntry, &eventSequence)
+	assert.NoError(t, err)
+	assert.Equal(t, app.GetAnnotations(), annotations)
+	assert.Equal(t, []NotificationDelivery{{
+		Trigger:      "my-trigger",
+		Destination:  dest,
+		DryRun:       true,
+		Notification: &services.Notification{Message: "hello"},
+	}}, eventSequence.Delivered)
+	assert.Contains(t, output.String(), "hello")
+
+	eventSequence = NotificationEventSequence{}
+	_, err = ctrl.processResource(app, logEntry, &eventSequence)
+	assert.NoError(t, err)
+	assert.Equal(t, []NotificationDelivery{{Trigger: "my-trigger", Destination: dest, AlreadyNotified: true}}, eventSequence.Delivered)
+}
+
+func TestWithDryRun_RemovesStateOfDeletedResource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test")
+	client := newFakeClient(app)
+
+	ctrl, _, err := newController(t, ctx, client, WithDryRun(nil))
+	assert.NoError(t, err)
+	ctrl.setDryRunState(app, NotificationsState{"my-trigger:0:mock:recipient": 1})
+
+	assert.NoError(t, client.Resource(testGVR).Namespace(testNamespace).Delete(context.Background(), "test", v1.DeleteOptions{}))
+	assert.Eventually(t, func() bool {
+		ctrl.dryRunStatesLock.Lock()
+		defer ctrl.dryRunStatesLock.Unlock()
+		return len(ctrl.dryRunStates) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSuppressInitialSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAPI := mocks.NewMockAPI(mockCtrl)
+	mockAPI.EXPECT().GetConfig().Return(api.Config{SuppressInitialSync: map[string]bool{"my-trigger": true}}).AnyTimes()
+	mockAPI.EXPECT().GetGlobalDestinations(gomock.Any(), gomock.Any()).Return(services.Destinations{}).AnyTimes()
+	mockAPI.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil).AnyTimes()
+
+	ctrl, _, err := newController(t, ctx, newFakeClient(app))
+	assert.NoError(t, err)
+	ctrl.apiFactory = &mocks.FakeFactory{Api: mockAPI}
+	ctrl.setInitialKeys([]string{"default/test"})
+
+	annotations, err := ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+	assert.Len(t, NewState(annotations[notifiedAnnotationKey]), 1)
+
+	ctrl.completeInitialSync("default/test")
+	mockAPI.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil)
+	_, err = ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+}
+
+func TestSuppressInitialSync_SkippedProcessingCompletesInitialSync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAPI := mocks.NewMockAPI(mockCtrl)
+	mockAPI.EXPECT().GetConfig().Return(api.Config{SuppressInitialSync: map[string]bool{"my-trigger": true}}).AnyTimes()
+	mockAPI.EXPECT().GetGlobalDestinations(gomock.Any(), gomock.Any()).Return(services.Destinations{}).AnyTimes()
+	mockAPI.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil).AnyTimes()
+
+	skip := true
+	ctrl, _, err := newController(t, ctx, newFakeClient(app), WithSkipProcessing(func(_ v1.Object) (bool, string) {
+		return skip, "resource is paused"
+	}))
+	assert.NoError(t, err)
+	ctrl.apiFactory = &mocks.FakeFactory{Api: mockAPI}
+	ctrl.setInitialKeys([]string{"default/test"})
+
+	ctrl.queue.Add("default/test")
+	ctrl.processQueueItem()
+	assert.False(t, ctrl.isInitialKey("default/test"))
+
+	skip = false
+	mockAPI.EXPECT().Send(gomock.Any(), []string{"test"}, services.Destination{Service: "mock", Recipient: "recipient"}).Return(nil)
+	_, err = ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+}
+
+func TestSuppressInitialSync_ClusterScoped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "recipient",
+	}))
+	app.SetNamespace("")
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAPI := mocks.NewMockAPI(mockCtrl)
+	mockAPI.EXPECT().GetConfig().Return(api.Config{SuppressInitialSync: map[string]bool{"my-trigger": true}}).AnyTimes()
+	mockAPI.EXPECT().GetGlobalDestinations(gomock.Any(), gomock.Any()).Return(services.Destinations{}).AnyTimes()
+	mockAPI.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{{Triggered: true, Templates: []string{"test"}}}, nil).AnyTimes()
+
+	ctrl, _, err := newController(t, ctx, newFakeClient())
+	assert.NoError(t, err)
+	ctrl.apiFactory = &mocks.FakeFactory{Api: mockAPI}
+	ctrl.setInitialKeys([]string{"test"})
+
+	annotations, err := ctrl.processResource(app, logEntry, &NotificationEventSequence{})
+	assert.NoError(t, err)
+	assert.Len(t, NewState(annotations[notifiedAnnotationKey]), 1)
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/notifications-engine/pkg/api"
+	"github.com/argoproj/notifications-engine/pkg/services"
+)
+
+// Explanation describes how the controller processes the resource
+type Explanation struct {
+	// Skipped holds the reason why the resource processing is skipped
+	Skipped string `json:"skipped,omitempty"`
+	// Triggers holds explanations of the triggers the resource is subscribed to, sorted by the trigger name
+	Triggers []TriggerExplanation `json:"triggers,omitempty"`
+	// State holds the notified state items stored in the resource annotation
+	State NotificationsState `json:"state,omitempty"`
+}
+
+// TriggerExplanation describes the trigger destinations and the condition results
+type TriggerExplanation struct {
+	Trigger      string                   `json:"trigger"`
+	Destinations []DestinationExplanation `json:"destinations"`
+	Conditions   []ConditionExplanation   `json:"conditions,omitempty"`
+	// Error holds the trigger evaluation error
+	Error string `json:"error,omitempty"`
+}
+
+// DestinationExplanation describes where the destination comes from
+type DestinationExplanation struct {
+	Destination services.Destination `json:"destination"`
+	Sources     []string             `json:"sources"`
+}
+
+// ConditionExplanation describes the condition result and the deliveries to each destination
+type ConditionExplanation struct {
+	Key        string                `json:"key"`
+	Triggered  bool                  `json:"triggered"`
+	Error      string                `json:"error,omitempty"`
+	Deliveries []DeliveryExplanation `json:"deliveries"`
+}
+
+// DeliveryExplanation describes if the notification would be sent to the destination now
+type DeliveryExplanation struct {
+	Destination services.Destination `json:"destination"`
+	// StateKey is the key of the notified state item
+	StateKey string `json:"stateKey"`
+	// NotifiedAt is the unix time of the previous notification, zero if the destination is not notified
+	NotifiedAt int64  `json:"notifiedAt,omitempty"`
+	WouldSend  bool   `json:"wouldSend"`
+	Reason     string `json:"reason"`
+}
+
+// ExplainResource explains how the controller created with the given options processes the resource. Notifications
+// are not sent and the resource is not updated.
+func ExplainResource(resource v1.Object, apiFactory api.Factory, opts ...Opts) (*Explanation, error) {
+	ctrl := &notificationController{apiFactory: apiFactory, toUnstructured: defaultToUnstructured}
+	for i := range opts {
+		opts[i](ctrl)
+	}
+	return ctrl.Explain(resource)
+}
+
+// Explain evaluates the triggers of the resource the same way as processing does, but does not send notifications and
+// does not update the notifications state
+func (c *notificationController) Explain(resource v1.Object) (*Explanation, error) {
+	notificationsState := c.getState(resource)
+	res := &Explanation{State: c.getState(resource)}
+	if c.skipProcessing != nil {
+		if skipProcessing, reason := c.skipProcessing(resource); skipProcessing {
+			res.Skipped = reason
+			return res, nil
+		}
+	}
+
+	api, err := c.apiFactory.GetAPI()
+	if err != nil {
+		return nil, err
+	}
+	un, err := c.toUnstructured(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := map[string][]string{}
+	for _, item := range GetDestinationsWithSources(api.GetConfig(), c.getDestinationSources(resource, un.Object, api)) {
+		sources[destinationKey(item.Trigger, item.Destination)] = item.Sources
+	}
+	destinations := c.getDestinations(resource, un.Object, api)
+	var triggerNames []string
+	for trigger := range destinations {
+		triggerNames = append(triggerNames, trigger)
+	}
+	sort.Strings(triggerNames)
+
+	now := time.Now()
+	for _, trigger := range triggerNames {
+		triggerExplanation := TriggerExplanation{Trigger: trigger}
+		for _, to := range destinations[trigger] {
+			triggerExplanation.Destinations = append(triggerExplanation.Destinations, DestinationExplanation{
+				Destination: to,
+				Sources:     explainSources(sources, trigger, to),
+			})
+		}
+
+		results, err := api.RunTrigger(trigger, un.Object)
+		if err != nil {
+			triggerExplanation.Error = err.Error()
+		}
+		for _, cr := range results {
+			conditionExplanation := ConditionExplanation{Key: cr.Key, Triggered: cr.Triggered}
+			if cr.Error != nil {
+				conditionExplanation.Error = cr.Error.Error()
+			}
+			for _, to := range destinations[trigger] {
+				notificationsState.Migrate(trigger, cr, to)
+				key := StateItemKey(trigger, cr, to)
+				delivery := DeliveryExplanation{Destination: to, StateKey: key, NotifiedAt: notificationsState[key]}
+				switch {
+				case !cr.Triggered:
+					delivery.Reason = "condition is not triggered"
+				case len(DestinationTemplates(api.GetConfig(), cr, to)) == 0:
+					delivery.Reason = "no templates configured for the service"
+				case to.Options.IsSilenced(now):
+					delivery.Reason = "destination is silenced"
+				case delivery.NotifiedAt != 0:
+					delivery.Reason = "already notified"
+				default:
+					delivery.WouldSend = true
+					delivery.Reason = "condition is triggered and destination is not notified yet"
+				}
+				conditionExplanation.Deliveries = append(conditionExplanation.Deliveries, delivery)
+			}
+			triggerExplanation.Conditions = append(triggerExplanation.Conditions, conditionExplanation)
+		}
+		res.Triggers = append(res.Triggers, triggerExplanation)
+	}
+	return res, nil
+}
+
+// explainSources returns names of the subscriptions that produce the destination
+func explainSources(sources map[string][]string, trigger string, to services.Destination) []string {
+	if res, ok := sources[destinationKey(trigger, to)]; ok {
+		return res
+	}
+	return []string{"altered destinations"}
+}
+
+func destinationKey(trigger string, to services.Destination) string {
+	return strings.Join([]string{trigger, to.Service, to.Recipient}, "\x00")
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/argoproj/notifications-engine/pkg/services"
+	"github.com/argoproj/notifications-engine/pkg/subscriptions"
+	"github.com/argoproj/notifications-engine/pkg/triggers"
+)
+
+func TestExplain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	notified := services.Destination{Service: "mock", Recipient: "notified"}
+	pending := services.Destination{Service: "mock", Recipient: "pending"}
+	app := newResource("test", withAnnotations(map[string]string{
+		subscriptions.SubscribeAnnotationKey("my-trigger", "mock"): "notified;pending",
+		notifiedAnnotationKey: mustToJson(map[string]int64{
+			StateItemKey("my-trigger", triggers.ConditionResult{Key: "0"}, notified): 1,
+		}),
+	}))
+
+	ctrl, api, err := newController(t, ctx, newFakeClient(app))
+	assert.NoError(t, err)
+	api.EXPECT().RunTrigger("my-trigger", gomock.Any()).Return([]triggers.ConditionResult{
+		{Key: "0", Triggered: true, Templates: []string{"my-template"}},
+		{Key: "1", Triggered: false, Templates: []string{"my-template"}},
+	}, nil)
+
+	explanation, err := ctrl.Explain(app)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, explanation.State, 1)
+	if !assert.Len(t, explanation.Triggers, 1) {
+		return
+	}
+	trigger := explanation.Triggers[0]
+	assert.Equal(t, []DestinationExplanation{
+		{Destination: notified, Sources: []string{"annotation notifications.argoproj.io/subscribe.my-trigger.mock"}},
+		{Destination: pending, Sources: []string{"annotation notifications.argoproj.io/subscribe.my-trigger.mock"}},
+	}, trigger.Destinations)
+	assert.Equal(t, []ConditionExplanation{{
+		Key:       "0",
+		Triggered: true,
+		Deliveries: []DeliveryExplanation{
+			{Destination: notified, StateKey: "my-trigger:0:mock:notified", NotifiedAt: 1, Reason: "already notified"},
+			{Destination: pending, StateKey: "my-trigger:0:mock:pending", WouldSend: true, Reason: "condition is triggered and destination is not notified yet"},
+		},
+	}, {
+		Key: "1",
+		Deliveries: []DeliveryExplanation{
+			{Destination: notified, StateKey: "my-trigger:1:mock:notified", Reason: "condition is not triggered"},
+			{Destination: pending, StateKey: "my-trigger:1:mock:pending", Reason: "condition is not triggered"},
+		},
+	}}, trigger.Conditions)
+}
+
+func TestExplain_SkipProcessing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	app := newResource("test")
+	ctrl, _, err := newController(t, ctx, newFakeClient(app), WithSkipProcessing(func(_ v1.Object) (bool, string) {
+		return true, "resource is paused"
+	}))
+	assert.NoError(t, err)
+
+	explanation, err := ctrl.Explain(app)
+	assert.NoError(t, err)
+	assert.Equal(t, "resource is paused", explanation.Skipped)
+}
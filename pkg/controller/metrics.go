@@ -16,6 +16,14 @@ func NewMetricsRegistry(prefix string) *MetricsRegistry {
 		[]string{"trigger", "service", "succeeded"},
 	)
 
+	dryRunDeliveriesCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_notifications_dry_run_deliveries_total", prefix),
+			Help: "Number of notifications rendered but not delivered in the dry-run mode.",
+		},
+		[]string{"trigger", "service", "succeeded"},
+	)
+
 	triggerEvaluationsCounter := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: fmt.Sprintf("%s_notifications_trigger_eval_total", prefix),
@@ -24,26 +32,48 @@ func NewMetricsRegistry(prefix string) *MetricsRegistry {
 		[]string{"name", "triggered"},
 	)
 
+	triggerEvaluationErrorsCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: fmt.Sprintf("%s_notifications_trigger_eval_errors_total", prefix),
+			Help: "Number of failed trigger condition evaluations.",
+		},
+		[]string{"name"},
+	)
+
 	registry := &MetricsRegistry{
-		Registry:                  prometheus.NewRegistry(),
-		deliveriesCounter:         deliveriesCounter,
-		triggerEvaluationsCounter: triggerEvaluationsCounter,
+		Registry:                       prometheus.NewRegistry(),
+		deliveriesCounter:              deliveriesCounter,
+		dryRunDeliveriesCounter:        dryRunDeliveriesCounter,
+		triggerEvaluationsCounter:      triggerEvaluationsCounter,
+		triggerEvaluationErrorsCounter: triggerEvaluationErrorsCounter,
 	}
 	registry.MustRegister(deliveriesCounter)
+	registry.MustRegister(dryRunDeliveriesCounter)
 	registry.MustRegister(triggerEvaluationsCounter)
+	registry.MustRegister(triggerEvaluationErrorsCounter)
 	return registry
 }
 
 type MetricsRegistry struct {
 	*prometheus.Registry
-	deliveriesCounter         *prometheus.CounterVec
-	triggerEvaluationsCounter *prometheus.CounterVec
+	deliveriesCounter              *prometheus.CounterVec
+	dryRunDeliveriesCounter        *prometheus.CounterVec
+	triggerEvaluationsCounter      *prometheus.CounterVec
+	triggerEvaluationErrorsCounter *prometheus.CounterVec
 }
 
 func (r *MetricsRegistry) IncDeliveriesCounter(trigger string, service string, succeeded bool) {
 	r.deliveriesCounter.WithLabelValues(trigger, service, strconv.FormatBool(succeeded)).Inc()
 }
 
+func (r *MetricsRegistry) IncDryRunDeliveriesCounter(trigger string, service string, succeeded bool) {
+	r.dryRunDeliveriesCounter.WithLabelValues(trigger, service, strconv.FormatBool(succeeded)).Inc()
+}
+
 func (r *MetricsRegistry) IncTriggerEvaluationsCounter(name string, triggered bool) {
 	r.triggerEvaluationsCounter.WithLabelValues(name, strconv.FormatBool(triggered)).Inc()
 }
+
+func (r *MetricsRegistry) IncTriggerEvaluationErrorsCounter(name string) {
+	r.triggerEvaluationErrorsCounter.WithLabelValues(name).Inc()
+}
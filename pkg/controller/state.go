@@ -3,7 +3,9 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sort"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -63,6 +65,102 @@ func (s NotificationsState) SetAlreadyNotified(trigger string, result triggers.C
 	return true
 }
 
+// Migrate moves the state of the given trigger/destination stored under the legacy condition key to the current key
+// and returns if state has been changed
+func (s NotificationsState) Migrate(trigger string, result triggers.ConditionResult, dest services.Destination) bool {
+	if result.LegacyKey == "" {
+		return false
+	}
+	key := StateItemKey(trigger, result, dest)
+	legacyResult := result
+	legacyResult.Key = result.LegacyKey
+	legacyKey := StateItemKey(trigger, legacyResult, dest)
+	notifiedAt, ok := s[legacyKey]
+	if !ok {
+		return false
+	}
+	delete(s, legacyKey)
+	if _, ok := s[key]; !ok {
+		s[key] = notifiedAt
+	}
+	return true
+}
+
+// StateFilter selects items of the notifications state. Empty fields match any item.
+type StateFilter struct {
+	// Trigger matches items of the trigger
+	Trigger string
+	// Destination matches items of the destination
+	Destination *services.Destination
+	// NotifiedBefore matches items notified before the specified time
+	NotifiedBefore time.Time
+}
+
+// stateItemKeyFields holds the fields of the state item key
+type stateItemKeyFields struct {
+	trigger   string
+	service   string
+	recipient string
+}
+
+// positionalConditionKey matches the key of the condition without id, e.g. [0].<hash of the when expression>
+var positionalConditionKey = regexp.MustCompile(`^\[\d+\]\.[\w-]+$`)
+
+// parseStateItemKey returns the possible fields of the state item key <once per>:<trigger>:<condition>:<service>:<recipient>.
+// The once per value and the recipient might contain ':', so the key is anchored by the positional condition key. The
+// condition id contains no ':', but cannot be told apart from other fields, so every interpretation is returned for
+// keys of conditions with id.
+func parseStateItemKey(key string) []stateItemKeyFields {
+	parts := strings.Split(key, ":")
+	var res []stateItemKeyFields
+	for i := 1; i+2 < len(parts); i++ {
+		if positionalConditionKey.MatchString(parts[i]) {
+			return []stateItemKeyFields{{trigger: parts[i-1], service: parts[i+1], recipient: strings.Join(parts[i+2:], ":")}}
+		}
+		res = append(res, stateItemKeyFields{trigger: parts[i-1], service: parts[i+1], recipient: strings.Join(parts[i+2:], ":")})
+	}
+	return res
+}
+
+// Matches returns true if the state item with the given key and notification time matches the filter
+func (f StateFilter) Matches(key string, notifiedAt int64) bool {
+	if !f.NotifiedBefore.IsZero() && notifiedAt >= f.NotifiedBefore.Unix() {
+		return false
+	}
+	for _, fields := range parseStateItemKey(key) {
+		if f.Trigger != "" && fields.trigger != f.Trigger {
+			continue
+		}
+		if f.Destination != nil && (fields.service != f.Destination.Service || fields.recipient != f.Destination.Recipient) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Keys returns sorted keys of the state items that match the filter
+func (s NotificationsState) Keys(filter StateFilter) []string {
+	var keys []string
+	for k, notifiedAt := range s {
+		if filter.Matches(k, notifiedAt) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Remove removes the state items that match the filter, so the notifications are sent again, and returns the
+// removed keys
+func (s NotificationsState) Remove(filter StateFilter) []string {
+	keys := s.Keys(filter)
+	for _, k := range keys {
+		delete(s, k)
+	}
+	return keys
+}
+
 func (s NotificationsState) Persist(res metav1.Object) (map[string]string, error) {
 	s.truncate(notifiedHistoryMaxSize)
 
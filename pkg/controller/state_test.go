@@ -3,6 +3,7 @@ package controller
 import (
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/argoproj/notifications-engine/pkg/triggers"
 
@@ -56,3 +57,56 @@ func TestSetAlreadyNotified_OncePerItem(t *testing.T) {
 	_, ok = state["abc:app-synced:0:slack:my-channel"]
 	assert.True(t, ok)
 }
+
+func TestMigrate(t *testing.T) {
+	dest := services.Destination{Service: "slack", Recipient: "my-channel"}
+	result := triggers.ConditionResult{Key: "synced", LegacyKey: "[0].abc"}
+
+	state := NotificationsState{"app-synced:[0].abc:slack:my-channel": 1}
+	changed := state.Migrate("app-synced", result, dest)
+
+	assert.True(t, changed)
+	assert.Equal(t, NotificationsState{"app-synced:synced:slack:my-channel": 1}, state)
+
+	changed = state.Migrate("app-synced", result, dest)
+	assert.False(t, changed)
+
+	changed = state.SetAlreadyNotified("app-synced", result, dest, true)
+	assert.False(t, changed)
+}
+
+func TestKeys_OncePerWithColons(t *testing.T) {
+	state := NotificationsState{
+		"2023-01-01T10:00:00Z:on-deployed:[0].abc:slack:ch":                100,
+		"2023-01-01T10:00:00Z:on-deployed:[0].abc:matrix:@ops:example.com": 200,
+		"2023-01-01T10:00:00Z:on-created:[0].abc:slack:on-deployed":        300,
+	}
+
+	assert.Equal(t, []string{
+		"2023-01-01T10:00:00Z:on-deployed:[0].abc:matrix:@ops:example.com",
+		"2023-01-01T10:00:00Z:on-deployed:[0].abc:slack:ch",
+	}, state.Keys(StateFilter{Trigger: "on-deployed"}))
+	assert.Equal(t, []string{"2023-01-01T10:00:00Z:on-deployed:[0].abc:matrix:@ops:example.com"},
+		state.Keys(StateFilter{Trigger: "on-deployed", Destination: &services.Destination{Service: "matrix", Recipient: "@ops:example.com"}}))
+}
+
+func TestKeysAndRemove(t *testing.T) {
+	state := NotificationsState{
+		"app-synced:0:slack:my-channel":                100,
+		"abc:app-synced:0:matrix:@ops:example.com":     200,
+		"app-deleted:0:slack:my-channel":               300,
+		"app-synced-with-suffix:0:slack:other-channel": 400,
+		"app-deleted:app-synced:slack:my-channel":      500,
+	}
+
+	assert.Equal(t, []string{"abc:app-synced:0:matrix:@ops:example.com", "app-synced:0:slack:my-channel"},
+		state.Keys(StateFilter{Trigger: "app-synced"}))
+	assert.Equal(t, []string{"abc:app-synced:0:matrix:@ops:example.com"},
+		state.Keys(StateFilter{Destination: &services.Destination{Service: "matrix", Recipient: "@ops:example.com"}}))
+	assert.Equal(t, []string{"abc:app-synced:0:matrix:@ops:example.com", "app-synced:0:slack:my-channel"},
+		state.Keys(StateFilter{NotifiedBefore: time.Unix(300, 0)}))
+
+	removed := state.Remove(StateFilter{Trigger: "app-synced", Destination: &services.Destination{Service: "slack", Recipient: "my-channel"}})
+	assert.Equal(t, []string{"app-synced:0:slack:my-channel"}, removed)
+	assert.Len(t, state, 4)
+}
@@ -62,6 +62,20 @@ func (mr *MockAPIMockRecorder) GetConfig() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockAPI)(nil).GetConfig))
 }
 
+// GetGlobalDestinations mocks base method.
+func (m *MockAPI) GetGlobalDestinations(arg0 map[string]interface{}, arg1 map[string]string) services.Destinations {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGlobalDestinations", arg0, arg1)
+	ret0, _ := ret[0].(services.Destinations)
+	return ret0
+}
+
+// GetGlobalDestinations indicates an expected call of GetGlobalDestinations.
+func (mr *MockAPIMockRecorder) GetGlobalDestinations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGlobalDestinations", reflect.TypeOf((*MockAPI)(nil).GetGlobalDestinations), arg0, arg1)
+}
+
 // GetNotificationServices mocks base method.
 func (m *MockAPI) GetNotificationServices() map[string]services.NotificationService {
 	m.ctrl.T.Helper()
@@ -76,6 +90,21 @@ func (mr *MockAPIMockRecorder) GetNotificationServices() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationServices", reflect.TypeOf((*MockAPI)(nil).GetNotificationServices))
 }
 
+// Render mocks base method.
+func (m *MockAPI) Render(arg0 map[string]interface{}, arg1 []string, arg2 services.Destination) (*services.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*services.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render.
+func (mr *MockAPIMockRecorder) Render(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockAPI)(nil).Render), arg0, arg1, arg2)
+}
+
 // RunTrigger mocks base method.
 func (m *MockAPI) RunTrigger(arg0 string, arg1 map[string]interface{}) ([]triggers.ConditionResult, error) {
 	m.ctrl.T.Helper()
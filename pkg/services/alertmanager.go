@@ -82,7 +82,7 @@ func (n AlertmanagerNotification) GetTemplater(name string, f texttemplate.FuncM
 			return err
 		}
 		var tempData bytes.Buffer
-		if err := tmpl.Execute(&tempData, vars); err != nil {
+		if err := executeTemplate(tmpl, &tempData, vars); err != nil {
 			return err
 		}
 		if val := tempData.String(); val != "" {
@@ -122,7 +122,7 @@ func (n *AlertmanagerNotification) parseAnnotations(name string, f texttemplate.
 		if err != nil {
 			return err
 		}
-		if err := tmpl.Execute(&tempData, vars); err != nil {
+		if err := executeTemplate(tmpl, &tempData, vars); err != nil {
 			return err
 		}
 		if val := tempData.String(); val != "" {
@@ -146,7 +146,7 @@ func (n *AlertmanagerNotification) parseLabels(name string, f texttemplate.FuncM
 		if err != nil {
 			return err
 		}
-		if err := tmpl.Execute(&tempData, vars); err != nil {
+		if err := executeTemplate(tmpl, &tempData, vars); err != nil {
 			return err
 		}
 		if val := tempData.String(); val != "" {
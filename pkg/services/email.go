@@ -29,7 +29,7 @@ func (n *EmailNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 			notification.Email = &EmailNotification{}
 		}
 		var emailSubjectData bytes.Buffer
-		if err := subject.Execute(&emailSubjectData, vars); err != nil {
+		if err := executeTemplate(subject, &emailSubjectData, vars); err != nil {
 			return err
 		}
 
@@ -38,7 +38,7 @@ func (n *EmailNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var emailBodyData bytes.Buffer
-		if err := body.Execute(&emailBodyData, vars); err != nil {
+		if err := executeTemplate(body, &emailBodyData, vars); err != nil {
 			return err
 		}
 		if val := emailBodyData.String(); val != "" {
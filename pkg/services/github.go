@@ -8,7 +8,6 @@ import (
 	"regexp"
 	"strings"
 	texttemplate "text/template"
-	"unicode/utf8"
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v41/github"
@@ -94,13 +93,13 @@ func (g *GitHubNotification) GetTemplater(name string, f texttemplate.FuncMap) (
 		}
 
 		var repoData bytes.Buffer
-		if err := repoURL.Execute(&repoData, vars); err != nil {
+		if err := executeTemplate(repoURL, &repoData, vars); err != nil {
 			return err
 		}
 		notification.GitHub.repoURL = repoData.String()
 
 		var revisionData bytes.Buffer
-		if err := revision.Execute(&revisionData, vars); err != nil {
+		if err := executeTemplate(revision, &revisionData, vars); err != nil {
 			return err
 		}
 		notification.GitHub.revision = revisionData.String()
@@ -111,19 +110,19 @@ func (g *GitHubNotification) GetTemplater(name string, f texttemplate.FuncMap) (
 			}
 
 			var stateData bytes.Buffer
-			if err := state.Execute(&stateData, vars); err != nil {
+			if err := executeTemplate(state, &stateData, vars); err != nil {
 				return err
 			}
 			notification.GitHub.Status.State = stateData.String()
 
 			var labelData bytes.Buffer
-			if err := label.Execute(&labelData, vars); err != nil {
+			if err := executeTemplate(label, &labelData, vars); err != nil {
 				return err
 			}
 			notification.GitHub.Status.Label = labelData.String()
 
 			var targetData bytes.Buffer
-			if err := targetURL.Execute(&targetData, vars); err != nil {
+			if err := executeTemplate(targetURL, &targetData, vars); err != nil {
 				return err
 			}
 			notification.GitHub.Status.TargetURL = targetData.String()
@@ -169,13 +168,6 @@ type gitHubService struct {
 	client *github.Client
 }
 
-func trunc(message string, n int) string {
-	if utf8.RuneCountInString(message) > n {
-		return string([]rune(message)[0:n-3]) + "..."
-	}
-	return message
-}
-
 func fullNameByRepoURL(rawURL string) string {
 	parsed, err := giturls.Parse(rawURL)
 	if err != nil {
@@ -198,7 +190,7 @@ func (g gitHubService) Send(notification Notification, _ Destination) error {
 	if notification.GitHub.Status != nil {
 		u := strings.Split(fullNameByRepoURL(notification.GitHub.repoURL), "/")
 		// maximum is 140 characters
-		description := trunc(notification.Message, 140)
+		description := text.Truncate(notification.Message, 140)
 		_, _, err := g.client.Repositories.CreateStatus(
 			context.Background(),
 			u[0],
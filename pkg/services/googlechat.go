@@ -65,7 +65,7 @@ func (n *GoogleChatNotification) GetTemplater(name string, f texttemplate.FuncMa
 			notification.GoogleChat = &GoogleChatNotification{}
 		}
 		var cardsBuff bytes.Buffer
-		if err := cards.Execute(&cardsBuff, vars); err != nil {
+		if err := executeTemplate(cards, &cardsBuff, vars); err != nil {
 			return err
 		}
 		if val := cardsBuff.String(); val != "" {
@@ -73,7 +73,7 @@ func (n *GoogleChatNotification) GetTemplater(name string, f texttemplate.FuncMa
 		}
 
 		var threadKeyBuff bytes.Buffer
-		if err := threadKey.Execute(&threadKeyBuff, vars); err != nil {
+		if err := executeTemplate(threadKey, &threadKeyBuff, vars); err != nil {
 			return err
 		}
 		if val := threadKeyBuff.String(); val != "" {
@@ -11,8 +11,12 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	httputil "github.com/argoproj/notifications-engine/pkg/util/http"
+	"github.com/argoproj/notifications-engine/pkg/util/text"
 )
 
+// mattermostMaxMessageLength is the maximum length of the post message accepted by Mattermost
+const mattermostMaxMessageLength = 16383
+
 type MattermostNotification struct {
 	Attachments string `json:"attachments,omitempty"`
 }
@@ -27,7 +31,7 @@ func (n *MattermostNotification) GetTemplater(name string, f texttemplate.FuncMa
 			notification.Mattermost = &MattermostNotification{}
 		}
 		var mattermostAttachmentsData bytes.Buffer
-		if err := mattermostAttachments.Execute(&mattermostAttachmentsData, vars); err != nil {
+		if err := executeTemplate(mattermostAttachments, &mattermostAttachmentsData, vars); err != nil {
 			return err
 		}
 
@@ -67,7 +71,7 @@ func (m *mattermostService) Send(notification Notification, dest Destination) er
 
 	body := map[string]interface{}{
 		"channel_id": dest.Recipient,
-		"message":    notification.Message,
+		"message":    text.Truncate(notification.Message, mattermostMaxMessageLength),
 		"props": map[string]interface{}{
 			"attachments": attachments,
 		},
@@ -66,25 +66,25 @@ func (n *NewrelicNotification) GetTemplater(name string, f texttemplate.FuncMap)
 			notification.Newrelic = &NewrelicNotification{}
 		}
 		var revisionData bytes.Buffer
-		if err := revision.Execute(&revisionData, vars); err != nil {
+		if err := executeTemplate(revision, &revisionData, vars); err != nil {
 			return err
 		}
 		notification.Newrelic.Revision = revisionData.String()
 
 		var changelogData bytes.Buffer
-		if err := changelog.Execute(&changelogData, vars); err != nil {
+		if err := executeTemplate(changelog, &changelogData, vars); err != nil {
 			return err
 		}
 		notification.Newrelic.Changelog = changelogData.String()
 
 		var descriptionData bytes.Buffer
-		if err := description.Execute(&descriptionData, vars); err != nil {
+		if err := executeTemplate(description, &descriptionData, vars); err != nil {
 			return err
 		}
 		notification.Newrelic.Description = descriptionData.String()
 
 		var userData bytes.Buffer
-		if err := user.Execute(&userData, vars); err != nil {
+		if err := executeTemplate(user, &userData, vars); err != nil {
 			return err
 		}
 		notification.Newrelic.User = userData.String()
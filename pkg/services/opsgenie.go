@@ -33,7 +33,7 @@ func (n *OpsgenieNotification) GetTemplater(name string, f texttemplate.FuncMap)
 			notification.Opsgenie = &OpsgenieNotification{}
 		}
 		var descData bytes.Buffer
-		if err := desc.Execute(&descData, vars); err != nil {
+		if err := executeTemplate(desc, &descData, vars); err != nil {
 			return err
 		}
 		notification.Opsgenie.Description = descData.String()
@@ -45,25 +45,25 @@ func (p *PagerDutyNotification) GetTemplater(name string, f texttemplate.FuncMap
 			notification.Pagerduty = &PagerDutyNotification{}
 		}
 		var titleData bytes.Buffer
-		if err := title.Execute(&titleData, vars); err != nil {
+		if err := executeTemplate(title, &titleData, vars); err != nil {
 			return err
 		}
 		notification.Pagerduty.Title = titleData.String()
 
 		var pdBodyData bytes.Buffer
-		if err := body.Execute(&pdBodyData, vars); err != nil {
+		if err := executeTemplate(body, &pdBodyData, vars); err != nil {
 			return err
 		}
 		notification.Pagerduty.Body = pdBodyData.String()
 
 		var pdUrgencyData bytes.Buffer
-		if err := urgency.Execute(&pdUrgencyData, vars); err != nil {
+		if err := executeTemplate(urgency, &pdUrgencyData, vars); err != nil {
 			return err
 		}
 		notification.Pagerduty.Urgency = pdUrgencyData.String()
 
 		var pdPriorityIDData bytes.Buffer
-		if err := priorityId.Execute(&pdPriorityIDData, vars); err != nil {
+		if err := executeTemplate(priorityId, &pdPriorityIDData, vars); err != nil {
 			return err
 		}
 		notification.Pagerduty.PriorityId = pdPriorityIDData.String()
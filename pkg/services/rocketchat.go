@@ -28,7 +28,7 @@ func (n *RocketChatNotification) GetTemplater(name string, f texttemplate.FuncMa
 			notification.RocketChat = &RocketChatNotification{}
 		}
 		var rocketChatAttachmentsData bytes.Buffer
-		if err := rocketChatAttachments.Execute(&rocketChatAttachmentsData, vars); err != nil {
+		if err := executeTemplate(rocketChatAttachments, &rocketChatAttachmentsData, vars); err != nil {
 			return err
 		}
 
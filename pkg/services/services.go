@@ -4,13 +4,38 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	texttemplate "text/template"
+	"time"
 	_ "time/tzdata"
 
 	"github.com/ghodss/yaml"
 )
 
+// TemplateWriterVarName is the name of the variable that holds the function wrapping the writer of every executed
+// notification template, e.g. to abort the rendering that exceeds the limits
+const TemplateWriterVarName = "__templateWriter"
+
+// TemplatePrepareVarName is the name of the variable that holds the function preparing every notification template
+// before the execution, e.g. to add shared templates or to bind functions to the current rendering
+const TemplatePrepareVarName = "__templatePrepare"
+
+// executeTemplate executes the template prepared by the TemplatePrepareVarName variable function into the writer
+// wrapped by the TemplateWriterVarName variable function
+func executeTemplate(tmpl *texttemplate.Template, w io.Writer, vars map[string]interface{}) error {
+	if prepare, ok := vars[TemplatePrepareVarName].(func(*texttemplate.Template) (*texttemplate.Template, error)); ok {
+		var err error
+		if tmpl, err = prepare(tmpl); err != nil {
+			return err
+		}
+	}
+	if wrap, ok := vars[TemplateWriterVarName].(func(io.Writer) io.Writer); ok {
+		w = wrap(w)
+	}
+	return tmpl.Execute(w, vars)
+}
+
 type Notification struct {
 	Message      string                    `json:"message,omitempty"`
 	Email        *EmailNotification        `json:"email,omitempty"`
@@ -36,15 +61,21 @@ func (s Destinations) Merge(other Destinations) {
 	}
 }
 
+// Dedup removes duplicated destinations. Options of the duplicates are merged, so that options of the later
+// destination take precedence.
 func (s Destinations) Dedup() Destinations {
 	for k, v := range s {
-		set := map[Destination]bool{}
+		index := map[Destination]int{}
 		var dedup []Destination
 		for _, dest := range v {
-			if !set[dest] {
-				set[dest] = true
-				dedup = append(dedup, dest)
+			key := dest
+			key.Options = nil
+			if i, ok := index[key]; ok {
+				dedup[i].Options = dedup[i].Options.Merge(dest.Options)
+				continue
 			}
+			index[key] = len(dedup)
+			dedup = append(dedup, dest)
 		}
 		s[k] = dedup
 	}
@@ -55,6 +86,87 @@ func (s Destinations) Dedup() Destinations {
 type Destination struct {
 	Service   string `json:"service"`
 	Recipient string `json:"recipient"`
+	// Locale is the preferred locale of the recipient, e.g. de or en-US
+	Locale string `json:"locale,omitempty"`
+	// Options holds optional settings of the subscription that produced the destination
+	Options *DestinationOptions `json:"options,omitempty"`
+}
+
+// DestinationOptions holds per-subscription settings of the destination
+type DestinationOptions struct {
+	// Templates overrides the templates of the trigger condition
+	Templates []string `json:"templates,omitempty"`
+	// Vars holds additional variables available in the templates as .vars
+	Vars map[string]interface{} `json:"vars,omitempty"`
+	// Severity is available in the templates as .severity
+	Severity string `json:"severity,omitempty"`
+	// Silence suppresses notifications to the destination
+	Silence *Silence `json:"silence,omitempty"`
+}
+
+// Silence holds settings of the suppressed destination
+type Silence struct {
+	// Until is the time when the silence expires. The destination is silenced indefinitely if not set.
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// IsEmpty returns true if no options are set
+func (o *DestinationOptions) IsEmpty() bool {
+	return o == nil || len(o.Templates) == 0 && len(o.Vars) == 0 && o.Severity == "" && o.Silence == nil
+}
+
+// Merge returns the options combined with the other options of the same destination that come from a more specific
+// subscription. Templates and severity of the other options take precedence, vars are merged with the keys of the
+// other options taking precedence. The destination is silenced if either of the options silences it, until the latest
+// of the silence times.
+func (o *DestinationOptions) Merge(other *DestinationOptions) *DestinationOptions {
+	if o == nil {
+		return other
+	}
+	if other == nil {
+		return o
+	}
+	res := *o
+	if len(other.Templates) > 0 {
+		res.Templates = other.Templates
+	}
+	if other.Severity != "" {
+		res.Severity = other.Severity
+	}
+	if len(other.Vars) > 0 {
+		res.Vars = map[string]interface{}{}
+		for k, v := range o.Vars {
+			res.Vars[k] = v
+		}
+		for k, v := range other.Vars {
+			res.Vars[k] = v
+		}
+	}
+	switch {
+	case res.Silence == nil:
+		res.Silence = other.Silence
+	case other.Silence == nil:
+	case res.Silence.Until == nil || other.Silence.Until == nil:
+		res.Silence = &Silence{}
+	case other.Silence.Until.After(*res.Silence.Until):
+		res.Silence = other.Silence
+	}
+	return &res
+}
+
+// IsSilenced returns true if notifications to the destination are suppressed at the given time
+func (o *DestinationOptions) IsSilenced(now time.Time) bool {
+	if o == nil || o.Silence == nil {
+		return false
+	}
+	return o.Silence.Until == nil || now.Before(*o.Silence.Until)
+}
+
+func (d Destination) String() string {
+	if d.Locale == "" {
+		return fmt.Sprintf("{%s %s}", d.Service, d.Recipient)
+	}
+	return fmt.Sprintf("{%s %s %s}", d.Service, d.Recipient, d.Locale)
 }
 
 func (n *Notification) GetTemplater(name string, f texttemplate.FuncMap) (Templater, error) {
@@ -241,7 +353,7 @@ func (n *Notification) getTemplater(name string, f texttemplate.FuncMap, sources
 
 	templaters := []Templater{func(notification *Notification, vars map[string]interface{}) error {
 		var messageData bytes.Buffer
-		if err := message.Execute(&messageData, vars); err != nil {
+		if err := executeTemplate(message, &messageData, vars); err != nil {
 			return err
 		}
 		if val := messageData.String(); val != "" {
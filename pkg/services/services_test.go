@@ -3,6 +3,7 @@ package services
 import (
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,3 +28,62 @@ func TestGetTemplater(t *testing.T) {
 
 	assert.Equal(t, "hello", notification.Message)
 }
+
+func TestDestinations_Dedup(t *testing.T) {
+	dests := Destinations{"my-trigger": {
+		{Service: "slack", Recipient: "my-channel", Options: &DestinationOptions{Severity: "critical"}},
+		{Service: "slack", Recipient: "my-channel"},
+		{Service: "slack", Recipient: "other-channel"},
+	}}
+
+	assert.Equal(t, Destinations{"my-trigger": {
+		{Service: "slack", Recipient: "my-channel", Options: &DestinationOptions{Severity: "critical"}},
+		{Service: "slack", Recipient: "other-channel"},
+	}}, dests.Dedup())
+}
+
+func TestDestinations_DedupMergesOptions(t *testing.T) {
+	dests := Destinations{"my-trigger": {
+		{Service: "slack", Recipient: "my-channel", Options: &DestinationOptions{Severity: "critical", Vars: map[string]interface{}{"a": "1"}}},
+		{Service: "slack", Recipient: "my-channel", Options: &DestinationOptions{
+			Severity:  "info",
+			Templates: []string{"short"},
+			Vars:      map[string]interface{}{"a": "2", "b": "3"},
+			Silence:   &Silence{},
+		}},
+	}}
+
+	assert.Equal(t, Destinations{"my-trigger": {
+		{Service: "slack", Recipient: "my-channel", Options: &DestinationOptions{
+			Severity:  "info",
+			Templates: []string{"short"},
+			Vars:      map[string]interface{}{"a": "2", "b": "3"},
+			Silence:   &Silence{},
+		}},
+	}}, dests.Dedup())
+}
+
+func TestDestinationOptions_MergeSilence(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	merged := (&DestinationOptions{Silence: &Silence{Until: &now}}).Merge(&DestinationOptions{Silence: &Silence{Until: &later}})
+	assert.Equal(t, &later, merged.Silence.Until)
+
+	merged = (&DestinationOptions{Silence: &Silence{Until: &now}}).Merge(&DestinationOptions{Silence: &Silence{}})
+	assert.Nil(t, merged.Silence.Until)
+
+	assert.Nil(t, (*DestinationOptions)(nil).Merge(nil))
+}
+
+func TestDestinationOptions_IsSilenced(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	assert.False(t, (*DestinationOptions)(nil).IsSilenced(now))
+	assert.False(t, (&DestinationOptions{}).IsSilenced(now))
+	assert.True(t, (&DestinationOptions{Silence: &Silence{}}).IsSilenced(now))
+	assert.True(t, (&DestinationOptions{Silence: &Silence{Until: &later}}).IsSilenced(now))
+	assert.False(t, (&DestinationOptions{Silence: &Silence{Until: &earlier}}).IsSilenced(now))
+}
@@ -12,6 +12,7 @@ import (
 
 	httputil "github.com/argoproj/notifications-engine/pkg/util/http"
 	slackutil "github.com/argoproj/notifications-engine/pkg/util/slack"
+	"github.com/argoproj/notifications-engine/pkg/util/text"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
@@ -48,19 +49,19 @@ func (n *SlackNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 			notification.Slack = &SlackNotification{}
 		}
 		var slackAttachmentsData bytes.Buffer
-		if err := slackAttachments.Execute(&slackAttachmentsData, vars); err != nil {
+		if err := executeTemplate(slackAttachments, &slackAttachmentsData, vars); err != nil {
 			return err
 		}
 		notification.Slack.Attachments = slackAttachmentsData.String()
 
 		var slackBlocksData bytes.Buffer
-		if err := slackBlocks.Execute(&slackBlocksData, vars); err != nil {
+		if err := executeTemplate(slackBlocks, &slackBlocksData, vars); err != nil {
 			return err
 		}
 		notification.Slack.Blocks = slackBlocksData.String()
 
 		var groupingKeyData bytes.Buffer
-		if err := groupingKey.Execute(&groupingKeyData, vars); err != nil {
+		if err := executeTemplate(groupingKey, &groupingKeyData, vars); err != nil {
 			return err
 		}
 		notification.Slack.GroupingKey = groupingKeyData.String()
@@ -87,12 +88,15 @@ type slackService struct {
 
 var validIconEmoji = regexp.MustCompile(`^:.+:$`)
 
+// slackMaxMessageLength is the maximum length of the message text accepted by Slack
+const slackMaxMessageLength = 40000
+
 func NewSlackService(opts SlackOptions) NotificationService {
 	return &slackService{opts: opts}
 }
 
 func buildMessageOptions(notification Notification, dest Destination, opts SlackOptions) (*SlackNotification, []slack.MsgOption, error) {
-	msgOptions := []slack.MsgOption{slack.MsgOptionText(notification.Message, false)}
+	msgOptions := []slack.MsgOption{slack.MsgOptionText(text.Truncate(notification.Message, slackMaxMessageLength), false)}
 	slackNotification := &SlackNotification{}
 
 	if opts.Username != "" {
@@ -71,7 +71,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var templateBuff bytes.Buffer
-		if err := template.Execute(&templateBuff, vars); err != nil {
+		if err := executeTemplate(template, &templateBuff, vars); err != nil {
 			return err
 		}
 		if val := templateBuff.String(); val != "" {
@@ -79,7 +79,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var titleBuff bytes.Buffer
-		if err := title.Execute(&titleBuff, vars); err != nil {
+		if err := executeTemplate(title, &titleBuff, vars); err != nil {
 			return err
 		}
 		if val := titleBuff.String(); val != "" {
@@ -87,7 +87,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var summaryBuff bytes.Buffer
-		if err := summary.Execute(&summaryBuff, vars); err != nil {
+		if err := executeTemplate(summary, &summaryBuff, vars); err != nil {
 			return err
 		}
 		if val := summaryBuff.String(); val != "" {
@@ -95,7 +95,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var textBuff bytes.Buffer
-		if err := text.Execute(&textBuff, vars); err != nil {
+		if err := executeTemplate(text, &textBuff, vars); err != nil {
 			return err
 		}
 		if val := textBuff.String(); val != "" {
@@ -103,7 +103,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var themeColorBuff bytes.Buffer
-		if err := themeColor.Execute(&themeColorBuff, vars); err != nil {
+		if err := executeTemplate(themeColor, &themeColorBuff, vars); err != nil {
 			return err
 		}
 		if val := themeColorBuff.String(); val != "" {
@@ -111,7 +111,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var factsData bytes.Buffer
-		if err := facts.Execute(&factsData, vars); err != nil {
+		if err := executeTemplate(facts, &factsData, vars); err != nil {
 			return err
 		}
 		if val := factsData.String(); val != "" {
@@ -119,7 +119,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var sectionsBuff bytes.Buffer
-		if err := sections.Execute(&sectionsBuff, vars); err != nil {
+		if err := executeTemplate(sections, &sectionsBuff, vars); err != nil {
 			return err
 		}
 		if val := sectionsBuff.String(); val != "" {
@@ -127,7 +127,7 @@ func (n *TeamsNotification) GetTemplater(name string, f texttemplate.FuncMap) (T
 		}
 
 		var actionsData bytes.Buffer
-		if err := potentialActions.Execute(&actionsData, vars); err != nil {
+		if err := executeTemplate(potentialActions, &actionsData, vars); err != nil {
 			return err
 		}
 		if val := actionsData.String(); val != "" {
@@ -5,8 +5,13 @@ import (
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/argoproj/notifications-engine/pkg/util/text"
 )
 
+// telegramMaxMessageLength is the maximum length of the message text accepted by Telegram
+const telegramMaxMessageLength = 4096
+
 type TelegramOptions struct {
 	Token string `json:"token"`
 }
@@ -32,7 +37,7 @@ func (s telegramService) Send(notification Notification, dest Destination) error
 		}
 
 		// Init message with ParseMode is 'Markdown'
-		msg := tgbotapi.NewMessage(chatID, notification.Message)
+		msg := tgbotapi.NewMessage(chatID, text.Truncate(notification.Message, telegramMaxMessageLength))
 		msg.ParseMode = "Markdown"
 
 		_, err = bot.Send(msg)
@@ -41,7 +46,7 @@ func (s telegramService) Send(notification Notification, dest Destination) error
 		}
 	} else {
 		// Init message with ParseMode is 'Markdown'
-		msg := tgbotapi.NewMessageToChannel("@"+dest.Recipient, notification.Message)
+		msg := tgbotapi.NewMessageToChannel("@"+dest.Recipient, text.Truncate(notification.Message, telegramMaxMessageLength))
 		msg.ParseMode = "Markdown"
 
 		_, err := bot.Send(msg)
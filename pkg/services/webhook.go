@@ -47,12 +47,12 @@ func (n WebhookNotifications) GetTemplater(name string, f texttemplate.FuncMap)
 				notification.Webhook = map[string]WebhookNotification{}
 			}
 			var body bytes.Buffer
-			err := webhooks[k].body.Execute(&body, vars)
+			err := executeTemplate(webhooks[k].body, &body, vars)
 			if err != nil {
 				return err
 			}
 			var path bytes.Buffer
-			err = webhooks[k].path.Execute(&path, vars)
+			err = executeTemplate(webhooks[k].path, &path, vars)
 			if err != nil {
 				return err
 			}
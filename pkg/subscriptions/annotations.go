@@ -35,7 +35,12 @@ func parseRecipients(v string) []string {
 	return recipients
 }
 
+// SubscribeAnnotationKey returns the key of the subscription annotation. The subscription without a trigger uses the
+// default triggers.
 func SubscribeAnnotationKey(trigger string, service string) string {
+	if trigger == "" {
+		return fmt.Sprintf("%s/subscribe.%s", annotationPrefix, service)
+	}
 	return fmt.Sprintf("%s/subscribe.%s.%s", annotationPrefix, trigger, service)
 }
 
@@ -52,15 +57,27 @@ func NewAnnotations(annotations map[string]string) Annotations {
 type Subscription struct {
 	Trigger      []string
 	Destinations []Destination
+	// DestinationOptions holds optional templates, variables, severity and silence settings of the subscription
+	services.DestinationOptions
+}
+
+// options returns the subscription options or nil if no options are set
+func (s Subscription) options() *services.DestinationOptions {
+	if s.DestinationOptions.IsEmpty() {
+		return nil
+	}
+	opts := s.DestinationOptions
+	return &opts
 }
 
 // Destination holds notification destination details
 type Destination struct {
 	Service    string   `json:"service"`
 	Recipients []string `json:"recipients"`
+	Locale     string   `json:"locale,omitempty"`
 }
 
-func (a Annotations) iterate(callback func(trigger string, service string, recipients []string, key string)) {
+func (a Annotations) iterate(callback func(trigger string, service string, recipients []string, locale string, options *services.DestinationOptions, key string)) {
 	prefix := annotationPrefix + "/subscribe."
 	altPrefix := annotationPrefix + "/subscriptions"
 	var recipients []string
@@ -81,7 +98,7 @@ func (a Annotations) iterate(callback func(trigger string, service string, recip
 			} else {
 				recipients = parseRecipients(v)
 			}
-			callback(trigger, service, recipients, k)
+			callback(trigger, service, recipients, "", nil, k)
 		case strings.HasPrefix(k, altPrefix):
 			var subscriptions []Subscription
 			var source []byte
@@ -89,12 +106,12 @@ func (a Annotations) iterate(callback func(trigger string, service string, recip
 				source = []byte(v)
 			} else {
 				log.Errorf("Subscription is not defined")
-				callback("", "", recipients, k)
+				callback("", "", recipients, "", nil, k)
 			}
 			err := yaml.Unmarshal(source, &subscriptions)
 			if err != nil {
 				log.Errorf("Notification subscription unrmashal error: %v", err)
-				callback("", "", recipients, k)
+				callback("", "", recipients, "", nil, k)
 			}
 			for _, v := range subscriptions {
 				triggers := v.Trigger
@@ -104,13 +121,13 @@ func (a Annotations) iterate(callback func(trigger string, service string, recip
 					destination := ""
 					recipients = []string{}
 					log.Printf("Notification triggers and destinations are not configured")
-					callback(trigger, destination, recipients, k)
+					callback(trigger, destination, recipients, "", nil, k)
 				} else if len(triggers) == 0 && len(destinations) != 0 {
 					trigger := ""
 					log.Printf("Notification triggers are not configured")
 					for _, destination := range destinations {
 						log.Printf("trigger: %v, service: %v, recipient: %v \n", trigger, destination.Service, destination.Recipients)
-						callback(trigger, destination.Service, destination.Recipients, k)
+						callback(trigger, destination.Service, destination.Recipients, destination.Locale, v.options(), k)
 					}
 				} else if len(triggers) != 0 && len(destinations) == 0 {
 					service := ""
@@ -118,19 +135,19 @@ func (a Annotations) iterate(callback func(trigger string, service string, recip
 					log.Printf("Notification destinations are not configured")
 					for _, trigger := range triggers {
 						log.Printf("trigger: %v, service: %v, recipient: %v \n", trigger, service, recipients)
-						callback(trigger, service, recipients, k)
+						callback(trigger, service, recipients, "", nil, k)
 					}
 				} else {
 					for _, trigger := range triggers {
 						for _, destination := range destinations {
 							log.Printf("Notification trigger: %v, service: %v, recipient: %v \n", trigger, destination.Service, destination.Recipients)
-							callback(trigger, destination.Service, destination.Recipients, k)
+							callback(trigger, destination.Service, destination.Recipients, destination.Locale, v.options(), k)
 						}
 					}
 				}
 			}
 		default:
-			callback("", "", recipients, k)
+			callback("", "", recipients, "", nil, k)
 		}
 	}
 }
@@ -152,15 +169,15 @@ func (a Annotations) Subscribe(trigger string, service string, recipients ...str
 }
 
 func (a Annotations) Unsubscribe(trigger string, service string, recipient string) {
-	a.iterate(func(t string, s string, r []string, k string) {
-		if trigger != t || s != service {
+	a.iterate(func(t string, s string, r []string, _ string, _ *services.DestinationOptions, k string) {
+		if trigger != t || s != service || !strings.HasPrefix(k, annotationPrefix+"/subscribe.") {
 			return
 		}
 		for i := range r {
 			if r[i] == recipient {
 				updatedRecipients := append(r[:i], r[i+1:]...)
 				if len(updatedRecipients) > 0 {
-					a[k] = strings.Join(updatedRecipients, "")
+					a[k] = strings.Join(updatedRecipients, ";")
 				} else {
 					delete(a, k)
 				}
@@ -172,7 +189,7 @@ func (a Annotations) Unsubscribe(trigger string, service string, recipient strin
 
 func (a Annotations) Has(service string, recipient string) bool {
 	has := false
-	a.iterate(func(t string, s string, r []string, k string) {
+	a.iterate(func(t string, s string, r []string, _ string, _ *services.DestinationOptions, k string) {
 		if s != service {
 			return
 		}
@@ -188,7 +205,7 @@ func (a Annotations) Has(service string, recipient string) bool {
 
 func (a Annotations) GetDestinations(defaultTriggers []string, serviceDefaultTriggers map[string][]string) services.Destinations {
 	dests := services.Destinations{}
-	a.iterate(func(trigger string, service string, recipients []string, v string) {
+	a.iterate(func(trigger string, service string, recipients []string, locale string, options *services.DestinationOptions, v string) {
 		for _, recipient := range recipients {
 			triggers := defaultTriggers
 			if trigger != "" {
@@ -201,6 +218,8 @@ func (a Annotations) GetDestinations(defaultTriggers []string, serviceDefaultTri
 				dests[triggers[i]] = append(dests[triggers[i]], services.Destination{
 					Service:   service,
 					Recipient: recipient,
+					Locale:    locale,
+					Options:   options,
 				})
 			}
 		}
@@ -121,7 +121,7 @@ func TestIterate(t *testing.T) {
 
 	for _, tt := range tests {
 		a := Annotations(tt.annotations)
-		a.iterate(func(trigger, service string, recipients []string, key string) {
+		a.iterate(func(trigger, service string, recipients []string, _ string, _ *services.DestinationOptions, key string) {
 			for _, v := range tt.triggers {
 				for _, serv := range tt.service {
 					if trigger == v {
@@ -223,6 +223,51 @@ func TestGetDestinations(t *testing.T) {
 				}},
 			},
 		},
+		{
+			subscriptions: Annotations(map[string]string{
+				"notifications.argoproj.io/subscriptions": `
+- trigger: [my-trigger]
+  destinations:
+  - service: slack
+    recipients: [my-channel]
+    locale: de
+`,
+			}),
+			result: services.Destinations{
+				"my-trigger": []services.Destination{{
+					Service:   "slack",
+					Recipient: "my-channel",
+					Locale:    "de",
+				}},
+			},
+		},
+		{
+			subscriptions: Annotations(map[string]string{
+				"notifications.argoproj.io/subscriptions": `
+- trigger: [my-trigger]
+  destinations:
+  - service: webhook
+    recipients: ["github:status"]
+  templates: [my-template]
+  vars:
+    team: platform
+  severity: critical
+  silence: {}
+`,
+			}),
+			result: services.Destinations{
+				"my-trigger": []services.Destination{{
+					Service:   "webhook",
+					Recipient: "github:status",
+					Options: &services.DestinationOptions{
+						Templates: []string{"my-template"},
+						Vars:      map[string]interface{}{"team": "platform"},
+						Severity:  "critical",
+						Silence:   &services.Silence{},
+					},
+				}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -258,6 +303,22 @@ func TestUnsubscribe(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestUnsubscribe_KeepsRemainingRecipients(t *testing.T) {
+	a := Annotations(map[string]string{
+		"notifications.argoproj.io/subscribe.my-trigger.slack": "my-channel1;my-channel2;my-channel3",
+		"notifications.argoproj.io/subscriptions":              data,
+	})
+	a.Unsubscribe("my-trigger", "slack", "my-channel1")
+	a.Unsubscribe("my-trigger1", "slack", "recipient-1")
+
+	assert.Equal(t, "my-channel2;my-channel3", a["notifications.argoproj.io/subscribe.my-trigger.slack"])
+	assert.Equal(t, data, a["notifications.argoproj.io/subscriptions"])
+}
+
+func TestSubscribeAnnotationKey_DefaultTriggers(t *testing.T) {
+	assert.Equal(t, "notifications.argoproj.io/subscribe.slack", SubscribeAnnotationKey("", "slack"))
+}
+
 func TestSetAnnotationPrefix(t *testing.T) {
 	origPrefix := annotationPrefix
 	defer func() {
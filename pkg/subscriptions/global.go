@@ -7,9 +7,11 @@ import (
 )
 
 type rawSubscription struct {
-	Recipients []string `json:"recipients"`
-	Triggers   []string `json:"triggers"`
-	Selector   string   `json:"selector"`
+	Recipients        []string `json:"recipients"`
+	Triggers          []string `json:"triggers"`
+	Selector          string   `json:"selector"`
+	Locale            string   `json:"locale,omitempty"`
+	NamespaceSelector string   `json:"namespaceSelector,omitempty"`
 }
 
 // DefaultSubscription holds recipients that receives notification by default.
@@ -20,6 +22,19 @@ type DefaultSubscription struct {
 	Triggers []string
 	// Options label selector that limits applied applications
 	Selector labels.Selector
+	// Optional locale of the recipients used to select localized templates
+	Locale string
+	// Optional label selector that limits namespaces of the applied applications
+	NamespaceSelector labels.Selector
+}
+
+// MatchesNamespace returns true if the subscription has no namespace selector or the selector matches the namespace labels.
+// The subscription with a namespace selector does not match if namespace labels are unknown.
+func (s *DefaultSubscription) MatchesNamespace(namespaceLabels map[string]string) bool {
+	if s.NamespaceSelector == nil || s.NamespaceSelector.Empty() {
+		return true
+	}
+	return namespaceLabels != nil && s.NamespaceSelector.Matches(labels.Set(namespaceLabels))
 }
 
 func (s *DefaultSubscription) MatchesTrigger(trigger string) bool {
@@ -41,11 +56,17 @@ func (s *DefaultSubscription) UnmarshalJSON(data []byte) error {
 	}
 	s.Triggers = raw.Triggers
 	s.Recipients = raw.Recipients
+	s.Locale = raw.Locale
 	selector, err := labels.Parse(raw.Selector)
 	if err != nil {
 		return err
 	}
 	s.Selector = selector
+	if raw.NamespaceSelector != "" {
+		if s.NamespaceSelector, err = labels.Parse(raw.NamespaceSelector); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -53,11 +74,25 @@ func (s *DefaultSubscription) MarshalJSON() ([]byte, error) {
 	raw := rawSubscription{
 		Triggers:   s.Triggers,
 		Recipients: s.Recipients,
+		Locale:     s.Locale,
 	}
 	if s.Selector != nil {
 		raw.Selector = s.Selector.String()
 	}
+	if s.NamespaceSelector != nil {
+		raw.NamespaceSelector = s.NamespaceSelector.String()
+	}
 	return json.Marshal(raw)
 }
 
 type DefaultSubscriptions []DefaultSubscription
+
+// HasNamespaceSelector returns true if any subscription limits namespaces of the applied applications
+func (s DefaultSubscriptions) HasNamespaceSelector() bool {
+	for i := range s {
+		if s[i].NamespaceSelector != nil && !s[i].NamespaceSelector.Empty() {
+			return true
+		}
+	}
+	return false
+}
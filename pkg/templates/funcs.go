@@ -0,0 +1,56 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	texttemplate "text/template"
+
+	"github.com/Masterminds/sprig/v3"
+
+	"github.com/argoproj/notifications-engine/pkg/util/kube"
+	"github.com/argoproj/notifications-engine/pkg/util/text"
+)
+
+// Funcs returns the built-in functions available in notification templates
+func Funcs() texttemplate.FuncMap {
+	f := sprig.TxtFuncMap()
+	delete(f, "env")
+	delete(f, "expandenv")
+	for name, fn := range kube.Funcs() {
+		f[name] = fn
+	}
+	for name, fn := range escapeFuncs() {
+		f[name] = fn
+	}
+	return f
+}
+
+// escapeFuncs returns functions that escape or convert text for the specific notification service
+func escapeFuncs() texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"jsonEscape":      jsonEscape,
+		"slackEscape":     text.SlackEscape,
+		"htmlEscape":      html.EscapeString,
+		"markdownToSlack": text.MarkdownToSlack,
+		"markdownToTeams": text.MarkdownToTeams,
+		"markdownToHTML":  text.MarkdownToHTML,
+	}
+}
+
+// jsonEscape returns the value formatted as a JSON string without surrounding quotes, so it can be safely
+// embedded into a JSON string literal
+func jsonEscape(val interface{}) string {
+	if val == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(fmt.Sprint(val)); err != nil {
+		return ""
+	}
+	res := bytes.TrimSpace(buf.Bytes())
+	return string(res[1 : len(res)-1])
+}
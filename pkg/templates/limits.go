@@ -0,0 +1,140 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+// Limits holds the notification rendering limits. Zero value of a limit means no limit.
+type Limits struct {
+	// Timeout is the maximum duration of the notification rendering
+	Timeout time.Duration
+	// MaxFieldSize is the maximum size in bytes of a single rendered notification field
+	MaxFieldSize int
+	// MaxListSize is the maximum number of elements produced by the until and untilStep functions
+	MaxListSize int
+}
+
+// DefaultLimits holds the rendering limits used by default
+var DefaultLimits = Limits{
+	Timeout:      5 * time.Second,
+	MaxFieldSize: 1024 * 1024,
+	MaxListSize:  10000,
+}
+
+type rawLimits struct {
+	Timeout      *string `json:"timeout,omitempty"`
+	MaxFieldSize *int    `json:"maxFieldSize,omitempty"`
+	MaxListSize  *int    `json:"maxListSize,omitempty"`
+}
+
+// UnmarshalJSON overrides the limits specified in the JSON document. The timeout is specified as a duration string, e.g. 10s
+func (l *Limits) UnmarshalJSON(data []byte) error {
+	var raw rawLimits
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Timeout != nil {
+		timeout, err := time.ParseDuration(*raw.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout '%s': %v", *raw.Timeout, err)
+		}
+		l.Timeout = timeout
+	}
+	if raw.MaxFieldSize != nil {
+		l.MaxFieldSize = *raw.MaxFieldSize
+	}
+	if raw.MaxListSize != nil {
+		l.MaxListSize = *raw.MaxListSize
+	}
+	return nil
+}
+
+var errRenderCancelled = errors.New("rendering is cancelled")
+
+// renderGuard aborts the notification rendering once it is cancelled or a rendered field exceeds the max size
+type renderGuard struct {
+	done         chan struct{}
+	cancelOnce   sync.Once
+	maxFieldSize int
+}
+
+func newRenderGuard(maxFieldSize int) *renderGuard {
+	return &renderGuard{done: make(chan struct{}), maxFieldSize: maxFieldSize}
+}
+
+func (g *renderGuard) cancel() {
+	g.cancelOnce.Do(func() {
+		close(g.done)
+	})
+}
+
+// err returns an error if the rendering is cancelled
+func (g *renderGuard) err() error {
+	select {
+	case <-g.done:
+		return errRenderCancelled
+	default:
+		return nil
+	}
+}
+
+// wrapWriter returns the writer that fails if the rendering is cancelled or the written data exceeds the max field size
+func (g *renderGuard) wrapWriter(w io.Writer) io.Writer {
+	return &limitedWriter{w: w, guard: g}
+}
+
+// funcs returns versions of the sprig list generating functions that fail if the rendering is cancelled or the list
+// is too long
+func (g *renderGuard) funcs(maxListSize int) map[string]interface{} {
+	generic := sprig.GenericFuncMap()
+	until := generic["until"].(func(int) []int)
+	untilStep := generic["untilStep"].(func(int, int, int) []int)
+	return map[string]interface{}{
+		"until": func(count int) ([]int, error) {
+			if err := g.err(); err != nil {
+				return nil, err
+			}
+			if maxListSize > 0 && count > maxListSize {
+				return nil, fmt.Errorf("until: list size %d exceeds the limit of %d elements", count, maxListSize)
+			}
+			return until(count), nil
+		},
+		"untilStep": func(start, stop, step int) ([]int, error) {
+			if err := g.err(); err != nil {
+				return nil, err
+			}
+			if maxListSize > 0 && step != 0 {
+				if size := (stop - start) / step; size > maxListSize {
+					return nil, fmt.Errorf("untilStep: list size %d exceeds the limit of %d elements", size, maxListSize)
+				}
+			}
+			return untilStep(start, stop, step), nil
+		},
+	}
+}
+
+// limitedWriter checks the render guard before every write
+type limitedWriter struct {
+	w       io.Writer
+	guard   *renderGuard
+	written int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if err := w.guard.err(); err != nil {
+		return 0, err
+	}
+	if w.guard.maxFieldSize > 0 && w.written+len(p) > w.guard.maxFieldSize {
+		return 0, fmt.Errorf("rendered field size exceeds the limit of %d bytes", w.guard.maxFieldSize)
+	}
+	n, err := w.w.Write(p)
+	w.written += n
+	return n, err
+}
@@ -1,11 +1,24 @@
 package templates
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-
-	"github.com/Masterminds/sprig/v3"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
 
 	"github.com/argoproj/notifications-engine/pkg/services"
+	"github.com/argoproj/notifications-engine/pkg/util/misc"
+)
+
+const (
+	// PartialPrefix is the prefix of the partial template names
+	PartialPrefix = "partial."
+	// LocaleVarName is the name of the variable that holds the recipient locale used to select localized templates
+	LocaleVarName = "locale"
 )
 
 type Service interface {
@@ -14,15 +27,67 @@ type Service interface {
 
 type service struct {
 	templaters map[string]services.Templater
+	partials   *texttemplate.Template
+	funcs      texttemplate.FuncMap
+	limits     Limits
+}
+
+type serviceOpts struct {
+	partials map[string]string
+	funcs    texttemplate.FuncMap
+	limits   Limits
+}
+
+type Opts func(opts *serviceOpts)
+
+// WithPartials registers templates that can be included into any notification template field using
+// {{ include "partial.<name>" . }} or {{ template "partial.<name>" . }}. Partials might reference each other using
+// {{ template "partial.<name>" . }}.
+func WithPartials(partials map[string]string) Opts {
+	return func(opts *serviceOpts) {
+		opts.partials = partials
+	}
+}
+
+// WithFuncs registers additional functions available in notification templates. The functions override
+// built-in functions with the same name.
+func WithFuncs(funcs texttemplate.FuncMap) Opts {
+	return func(opts *serviceOpts) {
+		opts.funcs = funcs
+	}
+}
+
+// WithLimits limits the notification rendering time and size of the rendered fields
+func WithLimits(limits Limits) Opts {
+	return func(opts *serviceOpts) {
+		opts.limits = limits
+	}
 }
 
-func NewService(templates map[string]services.Notification) (*service, error) {
-	f := sprig.TxtFuncMap()
-	delete(f, "env")
-	delete(f, "expandenv")
+func NewService(templates map[string]services.Notification, opts ...Opts) (*service, error) {
+	o := serviceOpts{}
+	for i := range opts {
+		opts[i](&o)
+	}
 
-	svc := &service{templaters: map[string]services.Templater{}}
+	svc := &service{templaters: map[string]services.Templater{}, funcs: o.funcs, limits: o.limits}
+	// templates are parsed once, every rendering clones them and binds the functions to the render guard
+	f := Funcs()
+	for name, fn := range o.funcs {
+		f[name] = fn
+	}
+	if len(o.partials) > 0 {
+		partials, err := parsePartials(o.partials, f)
+		if err != nil {
+			return nil, err
+		}
+		svc.partials = partials
+		f["include"] = svc.includeFunc(partials, nil)
+	}
 	for name, cfg := range templates {
+		if err := svc.validateTemplateRefs(name, cfg, f); err != nil {
+			return nil, err
+		}
 		templater, err := cfg.GetTemplater(name, f)
 		if err != nil {
 			return nil, err
@@ -32,18 +97,235 @@ func NewService(templates map[string]services.Notification) (*service, error) {
 	return svc, nil
 }
 
+// includeFunc returns the include function that executes the partial of the template set. If the guard is not nil,
+// the function fails once the guard is cancelled or the partial output exceeds the max field size.
+func (s *service) includeFunc(tmpl *texttemplate.Template, guard *renderGuard) func(name string, data interface{}) (string, error) {
+	return func(name string, data interface{}) (string, error) {
+		var buf bytes.Buffer
+		var w io.Writer = &buf
+		if guard != nil {
+			if err := guard.err(); err != nil {
+				return "", err
+			}
+			w = guard.wrapWriter(w)
+		}
+		if err := tmpl.ExecuteTemplate(w, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+}
+
+// prepareFunc returns the function that prepares every executed field template: the parsed template is cloned, the
+// partials are added to the clone and the include and list generating functions are bound to the guard
+func (s *service) prepareFunc(guard *renderGuard) func(tmpl *texttemplate.Template) (*texttemplate.Template, error) {
+	return func(tmpl *texttemplate.Template) (*texttemplate.Template, error) {
+		res, err := tmpl.Clone()
+		if err != nil {
+			return nil, err
+		}
+		f := texttemplate.FuncMap{}
+		if s.partials != nil {
+			for _, partial := range s.partials.Templates() {
+				if partial.Tree == nil || res.Lookup(partial.Name()) != nil {
+					continue
+				}
+				if _, err := res.AddParseTree(partial.Name(), partial.Tree); err != nil {
+					return nil, err
+				}
+			}
+			f["include"] = s.includeFunc(res, guard)
+		}
+		if guard != nil {
+			for name, fn := range guard.funcs(s.limits.MaxListSize) {
+				// custom functions override the built-in ones
+				if _, ok := s.funcs[name]; !ok {
+					f[name] = fn
+				}
+			}
+		}
+		return res.Funcs(f), nil
+	}
+}
+
+// parsePartials parses all partials into a single template set, so they can reference each other.
+// The include function is intentionally not available in partials to avoid unbounded recursion.
+func parsePartials(partials map[string]string, f texttemplate.FuncMap) (*texttemplate.Template, error) {
+	res := texttemplate.New("").Funcs(f)
+	var err error
+	misc.IterateStringKeyMap(partials, func(name string) {
+		if err != nil {
+			return
+		}
+		if _, err = res.New(PartialPrefix + name).Parse(partials[name]); err != nil {
+			err = fmt.Errorf("failed to parse partial '%s': %v", name, err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, partial := range res.Templates() {
+		if name, ok := undefinedTemplateRef(partial, res); ok {
+			return nil, fmt.Errorf("partial '%s' references undefined template '%s'", strings.TrimPrefix(partial.Name(), PartialPrefix), name)
+		}
+	}
+	return res, nil
+}
+
+// validateTemplateRefs returns an error if a field of the notification template references a template that is
+// neither defined in the field nor a partial, so the template fails when the service is created instead of on delivery
+func (s *service) validateTemplateRefs(name string, n services.Notification, f texttemplate.FuncMap) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	var fields interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	return walkStrings(fields, func(val string) error {
+		tmpl, err := texttemplate.New(name).Funcs(f).Parse(val)
+		if err != nil {
+			return err
+		}
+		for _, t := range tmpl.Templates() {
+			if ref, ok := undefinedTemplateRef(t, tmpl); ok && (s.partials == nil || s.partials.Lookup(ref) == nil) {
+				return fmt.Errorf("template '%s' references undefined template '%s'", name, ref)
+			}
+		}
+		return nil
+	})
+}
+
+func walkStrings(val interface{}, callback func(val string) error) error {
+	switch v := val.(type) {
+	case string:
+		return callback(v)
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := walkStrings(item, callback); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := walkStrings(item, callback); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// undefinedTemplateRef returns the name of the first template referenced by the template action that is not defined
+// in the template set
+func undefinedTemplateRef(tmpl *texttemplate.Template, set *texttemplate.Template) (string, bool) {
+	if tmpl.Tree == nil {
+		return "", false
+	}
+	var visit func(list *parse.ListNode) (string, bool)
+	visit = func(list *parse.ListNode) (string, bool) {
+		if list == nil {
+			return "", false
+		}
+		for _, node := range list.Nodes {
+			var branch *parse.BranchNode
+			switch n := node.(type) {
+			case *parse.TemplateNode:
+				if set.Lookup(n.Name) == nil {
+					return n.Name, true
+				}
+			case *parse.IfNode:
+				branch = &n.BranchNode
+			case *parse.RangeNode:
+				branch = &n.BranchNode
+			case *parse.WithNode:
+				branch = &n.BranchNode
+			}
+			if branch != nil {
+				if name, ok := visit(branch.List); ok {
+					return name, ok
+				}
+				if name, ok := visit(branch.ElseList); ok {
+					return name, ok
+				}
+			}
+		}
+		return "", false
+	}
+	return visit(tmpl.Tree.Root)
+}
+
 func (s *service) FormatNotification(vars map[string]interface{}, templates ...string) (*services.Notification, error) {
+	if s.limits == (Limits{}) && s.partials == nil {
+		return s.formatNotification(vars, templates...)
+	}
+
+	var guard *renderGuard
+	in := make(map[string]interface{}, len(vars)+2)
+	for k, v := range vars {
+		in[k] = v
+	}
+	if s.limits != (Limits{}) {
+		guard = newRenderGuard(s.limits.MaxFieldSize)
+		in[services.TemplateWriterVarName] = guard.wrapWriter
+	}
+	in[services.TemplatePrepareVarName] = s.prepareFunc(guard)
+	if s.limits.Timeout <= 0 {
+		return s.formatNotification(in, templates...)
+	}
+
+	type result struct {
+		notification *services.Notification
+		err          error
+	}
+	done := make(chan result, 1)
+	go func() {
+		notification, err := s.formatNotification(in, templates...)
+		done <- result{notification, err}
+	}()
+
+	timer := time.NewTimer(s.limits.Timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res.notification, res.err
+	case <-timer.C:
+		// the rendering goroutine stops on the next write or until, untilStep or include call
+		guard.cancel()
+		return nil, fmt.Errorf("rendering of templates %s exceeded the time limit of %v", strings.Join(templates, ", "), s.limits.Timeout)
+	}
+}
+
+func (s *service) formatNotification(vars map[string]interface{}, templates ...string) (*services.Notification, error) {
+	locale, _ := vars[LocaleVarName].(string)
 	var notification services.Notification
 	for _, templateName := range templates {
-		templater, ok := s.templaters[templateName]
+		name, ok := s.resolveTemplateName(templateName, locale)
 		if !ok {
 			return nil, fmt.Errorf("template '%s' is not supported", templateName)
 		}
-
-		if err := templater(&notification, vars); err != nil {
+		if err := s.templaters[name](&notification, vars); err != nil {
 			return nil, err
 		}
 	}
 
 	return &notification, nil
 }
+
+// resolveTemplateName returns the name of the template variant that best matches the locale: <name>.<locale>, then
+// <name>.<language> and finally <name>
+func (s *service) resolveTemplateName(name string, locale string) (string, bool) {
+	for locale != "" {
+		if _, ok := s.templaters[name+"."+locale]; ok {
+			return name + "." + locale, true
+		}
+		if i := strings.LastIndexAny(locale, "-_"); i > 0 {
+			locale = locale[:i]
+		} else {
+			locale = ""
+		}
+	}
+	_, ok := s.templaters[name]
+	return name, ok
+}
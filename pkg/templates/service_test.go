@@ -1,7 +1,9 @@
 package templates
 
 import (
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -29,3 +31,222 @@ func TestFormat_Message(t *testing.T) {
 
 	assert.Equal(t, "hello", notification.Message)
 }
+
+func TestFormat_Partials(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {
+			Message: `{{.foo}} {{ include "partial.footer" . | upper }}`,
+			Slack: &services.SlackNotification{
+				Blocks: `[{{ include "partial.block" . }}]`,
+			},
+		},
+	}, WithPartials(map[string]string{
+		"footer": `sent by {{ template "partial.sender" . }}`,
+		"sender": `{{.sender}}`,
+		"block":  `{"type": "section", "text": "{{.foo}}"}`,
+	}))
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	notification, err := svc.FormatNotification(map[string]interface{}{
+		"foo":    "hello",
+		"sender": "bot",
+	}, "test")
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "hello SENT BY BOT", notification.Message)
+	assert.Equal(t, `[{"type": "section", "text": "hello"}]`, notification.Slack.Blocks)
+}
+
+func TestFormat_MissingPartial(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {
+			Message: `{{ include "partial.missing" . }}`,
+		},
+	}, WithPartials(map[string]string{"footer": "footer"}))
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = svc.FormatNotification(map[string]interface{}{}, "test")
+	assert.Error(t, err)
+}
+
+func TestFormat_TemplateActionPartial(t *testing.T) {
+	for _, limits := range []Limits{{}, DefaultLimits} {
+		svc, err := NewService(map[string]services.Notification{
+			"test": {
+				Message: `{{.foo}} {{ template "partial.footer" . }}`,
+			},
+		}, WithPartials(map[string]string{"footer": `sent by {{.sender}}`}), WithLimits(limits))
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		notification, err := svc.FormatNotification(map[string]interface{}{"foo": "hello", "sender": "bot"}, "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "hello sent by bot", notification.Message)
+		}
+	}
+}
+
+func TestNewService_UndefinedTemplateRef(t *testing.T) {
+	_, err := NewService(map[string]services.Notification{
+		"test": {
+			Message: `{{ template "partial.missing" . }}`,
+		},
+	}, WithPartials(map[string]string{"footer": "footer"}))
+	assert.EqualError(t, err, "template 'test' references undefined template 'partial.missing'")
+
+	_, err = NewService(map[string]services.Notification{
+		"test": {
+			Slack: &services.SlackNotification{Blocks: `{{ if .foo }}{{ template "partial.footer" . }}{{ end }}`},
+		},
+	})
+	assert.EqualError(t, err, "template 'test' references undefined template 'partial.footer'")
+
+	_, err = NewService(map[string]services.Notification{}, WithPartials(map[string]string{
+		"footer": `{{ template "partial.missing" . }}`,
+	}))
+	assert.EqualError(t, err, "partial 'footer' references undefined template 'partial.missing'")
+}
+
+func TestNewService_InvalidPartial(t *testing.T) {
+	_, err := NewService(map[string]services.Notification{}, WithPartials(map[string]string{
+		"footer": `{{ include "partial.footer" . }}`,
+	}))
+
+	assert.Error(t, err)
+}
+
+func TestFormat_Funcs(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {
+			Message: "{{ shout .foo }} {{ upper .foo }}",
+		},
+	}, WithFuncs(map[string]interface{}{
+		"shout": func(val string) string {
+			return val + "!"
+		},
+		"upper": func(val string) string {
+			return "overridden"
+		},
+	}))
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	notification, err := svc.FormatNotification(map[string]interface{}{
+		"foo": "hello",
+	}, "test")
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "hello! overridden", notification.Message)
+}
+
+func TestFormat_Localized(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test":    {Message: "hello {{.foo}}"},
+		"test.de": {Message: "hallo {{.foo}}"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for locale, expected := range map[string]string{
+		"":      "hello world",
+		"de":    "hallo world",
+		"de-AT": "hallo world",
+		"fr":    "hello world",
+	} {
+		notification, err := svc.FormatNotification(map[string]interface{}{"foo": "world", LocaleVarName: locale}, "test")
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, notification.Message, locale)
+		}
+	}
+}
+
+func TestFormat_MaxFieldSize(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {
+			Message: "hello",
+			Slack:   &services.SlackNotification{Blocks: `{{ repeat 20 "a" }}`},
+		},
+	}, WithLimits(Limits{MaxFieldSize: 10}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = svc.FormatNotification(map[string]interface{}{}, "test")
+	assert.EqualError(t, err, "rendered field size exceeds the limit of 10 bytes")
+}
+
+func TestFormat_MaxFieldSizeInLoop(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {Message: `{{ range until 10000 }}{{ range until 10000 }}a{{ end }}{{ end }}`},
+	}, WithLimits(Limits{MaxFieldSize: 10}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = svc.FormatNotification(map[string]interface{}{}, "test")
+	assert.EqualError(t, err, "rendered field size exceeds the limit of 10 bytes")
+}
+
+func TestFormat_MaxListSize(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {Message: "{{ range until 1000 }}a{{ end }}"},
+	}, WithLimits(Limits{MaxListSize: 100}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = svc.FormatNotification(map[string]interface{}{}, "test")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "list size 1000 exceeds the limit of 100 elements")
+}
+
+func TestFormat_Timeout(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {Message: "{{ wait }}"},
+	}, WithLimits(Limits{Timeout: 10 * time.Millisecond}), WithFuncs(map[string]interface{}{
+		"wait": func() string {
+			time.Sleep(time.Second)
+			return ""
+		},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = svc.FormatNotification(map[string]interface{}{}, "test")
+	assert.EqualError(t, err, "rendering of templates test exceeded the time limit of 10ms")
+}
+
+func TestFormat_TimeoutStopsRendering(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {Message: `{{ range until 10000 }}{{ range until 10000 }}{{ range until 10000 }}{{ end }}{{ end }}{{ end }}`},
+	}, WithLimits(Limits{Timeout: 10 * time.Millisecond, MaxListSize: 10000}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	goroutines := runtime.NumGoroutine()
+	_, err = svc.FormatNotification(map[string]interface{}{}, "test")
+	assert.EqualError(t, err, "rendering of templates test exceeded the time limit of 10ms")
+
+	assert.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= goroutines
+	}, time.Second, 10*time.Millisecond)
+}
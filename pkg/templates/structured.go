@@ -0,0 +1,190 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/argoproj/notifications-engine/pkg/services"
+)
+
+// structuredFields holds paths of the notification fields that contain JSON documents. Such fields might be
+// specified as structured YAML instead of a templated JSON string.
+var structuredFields = [][]string{
+	{"slack", "attachments"},
+	{"slack", "blocks"},
+	{"mattermost", "attachments"},
+	{"rocketchat", "attachments"},
+	{"teams", "facts"},
+	{"teams", "sections"},
+	{"teams", "potentialAction"},
+	{"googlechat", "cards"},
+	{"webhook", "*", "body"},
+}
+
+// UnmarshalNotification parses notification template from YAML. Structured values of the fields that hold JSON
+// documents are converted into JSON templates: every string of the structure is treated as a template and its
+// output is escaped, so that the rendered field is always valid JSON.
+func UnmarshalNotification(data []byte) (services.Notification, error) {
+	var notification services.Notification
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return notification, err
+	}
+	converted := false
+	for _, path := range structuredFields {
+		changed, err := convertStructuredField(raw, path)
+		if err != nil {
+			return notification, err
+		}
+		converted = converted || changed
+	}
+	if converted {
+		var err error
+		if data, err = yaml.Marshal(raw); err != nil {
+			return notification, err
+		}
+	}
+	err := yaml.Unmarshal(data, &notification)
+	return notification, err
+}
+
+func convertStructuredField(node map[string]interface{}, path []string) (bool, error) {
+	if path[0] == "*" {
+		converted := false
+		for k := range node {
+			changed, err := convertStructuredField(node, append([]string{k}, path[1:]...))
+			if err != nil {
+				return false, err
+			}
+			converted = converted || changed
+		}
+		return converted, nil
+	}
+	val, ok := node[path[0]]
+	if !ok {
+		return false, nil
+	}
+	if len(path) > 1 {
+		if child, ok := val.(map[string]interface{}); ok {
+			return convertStructuredField(child, path[1:])
+		}
+		return false, nil
+	}
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		res, err := toJSONTemplate(val)
+		if err != nil {
+			return false, fmt.Errorf("failed to convert structured field '%s': %v", path[0], err)
+		}
+		node[path[0]] = res
+		return true, nil
+	}
+	return false, nil
+}
+
+// toJSONTemplate converts the structure into a template that produces JSON document
+func toJSONTemplate(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var items []string
+		for _, k := range keys {
+			key, err := toJSONStringTemplate(k)
+			if err != nil {
+				return "", err
+			}
+			item, err := toJSONTemplate(v[k])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, key+":"+item)
+		}
+		return "{" + strings.Join(items, ",") + "}", nil
+	case []interface{}:
+		var items []string
+		for i := range v {
+			item, err := toJSONTemplate(v[i])
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return "[" + strings.Join(items, ",") + "]", nil
+	case string:
+		return toJSONStringTemplate(v)
+	default:
+		data, err := json.Marshal(v)
+		return string(data), err
+	}
+}
+
+// toJSONStringTemplate converts the template into a template that produces JSON string literal by escaping
+// the static text and piping the output of every action into the jsonEscape function
+func toJSONStringTemplate(text string) (string, error) {
+	tree := parse.New("structured")
+	tree.Mode = parse.SkipFuncCheck
+	treeSet := map[string]*parse.Tree{}
+	if _, err := tree.Parse(text, "", "", treeSet); err != nil {
+		return "", err
+	}
+	if len(treeSet) > 1 {
+		return "", fmt.Errorf("template definitions are not supported in structured fields")
+	}
+	if tree.Root == nil {
+		return `""`, nil
+	}
+	if err := escapeJSONNodes(tree.Root); err != nil {
+		return "", err
+	}
+	return `"` + tree.Root.String() + `"`, nil
+}
+
+func escapeJSONNodes(list *parse.ListNode) error {
+	if list == nil {
+		return nil
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			n.Text = []byte(jsonEscape(string(n.Text)))
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) == 0 {
+				n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+					NodeType: parse.NodeCommand,
+					Args:     []parse.Node{parse.NewIdentifier("jsonEscape")},
+				})
+			}
+		case *parse.IfNode:
+			if err := escapeJSONBranch(&n.BranchNode); err != nil {
+				return err
+			}
+		case *parse.RangeNode:
+			if err := escapeJSONBranch(&n.BranchNode); err != nil {
+				return err
+			}
+		case *parse.WithNode:
+			if err := escapeJSONBranch(&n.BranchNode); err != nil {
+				return err
+			}
+		case *parse.TemplateNode:
+			return fmt.Errorf("template action is not supported in structured fields, use include function instead")
+		}
+	}
+	return nil
+}
+
+func escapeJSONBranch(branch *parse.BranchNode) error {
+	if err := escapeJSONNodes(branch.List); err != nil {
+		return err
+	}
+	return escapeJSONNodes(branch.ElseList)
+}
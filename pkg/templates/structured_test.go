@@ -0,0 +1,111 @@
+package templates
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/notifications-engine/pkg/services"
+)
+
+func TestUnmarshalNotification_StringFields(t *testing.T) {
+	n, err := UnmarshalNotification([]byte(`
+message: 123
+slack:
+  blocks: '[{"type": "divider"}]'
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, services.Notification{
+		Message: "123",
+		Slack:   &services.SlackNotification{Blocks: `[{"type": "divider"}]`},
+	}, n)
+}
+
+func TestUnmarshalNotification_StructuredFields(t *testing.T) {
+	n, err := UnmarshalNotification([]byte(`
+message: hello
+slack:
+  blocks:
+  - type: section
+    text:
+      type: mrkdwn
+      text: "Commit: {{ .commit }}{{ if .author }} by {{ .author }}{{ end }}"
+  - type: divider
+    block_id: 1
+webhook:
+  my-webhook:
+    method: POST
+    body:
+      message: '{{ .commit }}'
+      items: ['{{ range .items }}{{ . }};{{ end }}']
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	svc, err := NewService(map[string]services.Notification{"test": n})
+	if !assert.NoError(t, err) {
+		return
+	}
+	notification, err := svc.FormatNotification(map[string]interface{}{
+		"commit": "fix \"quotes\"\nand newlines",
+		"author": "<john>",
+		"items":  []string{"a", `b"`},
+	}, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var blocks []map[string]interface{}
+	if assert.NoError(t, json.Unmarshal([]byte(notification.Slack.Blocks), &blocks)) {
+		assert.Equal(t, []map[string]interface{}{{
+			"type": "section",
+			"text": map[string]interface{}{"type": "mrkdwn", "text": "Commit: fix \"quotes\"\nand newlines by <john>"},
+		}, {
+			"type":     "divider",
+			"block_id": float64(1),
+		}}, blocks)
+	}
+
+	var body map[string]interface{}
+	if assert.NoError(t, json.Unmarshal([]byte(notification.Webhook["my-webhook"].Body), &body)) {
+		assert.Equal(t, map[string]interface{}{
+			"message": "fix \"quotes\"\nand newlines",
+			"items":   []interface{}{`a;b";`},
+		}, body)
+	}
+	assert.Equal(t, "POST", notification.Webhook["my-webhook"].Method)
+}
+
+func TestUnmarshalNotification_UnsupportedTemplateAction(t *testing.T) {
+	_, err := UnmarshalNotification([]byte(`
+teams:
+  facts:
+  - name: '{{ template "partial.name" . }}'
+`))
+	assert.Error(t, err)
+}
+
+func TestEscapeFuncs(t *testing.T) {
+	svc, err := NewService(map[string]services.Notification{
+		"test": {
+			Message: `{"text": "{{ jsonEscape .text }}"} {{ slackEscape .html }} {{ htmlEscape .html }} {{ markdownToSlack .md }}`,
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	notification, err := svc.FormatNotification(map[string]interface{}{
+		"text": "a \"b\"\n<c>",
+		"html": "<b>&",
+		"md":   "**bold**",
+	}, "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `{"text": "a \"b\"\n<c>"} &lt;b&gt;&amp; &lt;b&gt;&amp; *bold*`, notification.Message)
+}
@@ -0,0 +1,208 @@
+package templatetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/argoproj/notifications-engine/pkg/services"
+)
+
+// TestCase holds the resource fixture and the template that is rendered for each service and compared with the golden file
+type TestCase struct {
+	// Name is the name of the test case file without the extension
+	Name string `json:"-"`
+	// Resource is the path of the resource fixture relative to the test case file
+	Resource string `json:"resource"`
+	// Template is the name of the rendered template
+	Template string `json:"template"`
+	// Services holds names of the services the template is rendered for
+	Services []string `json:"services"`
+	// Golden is the path of the golden file relative to the test case file, defaults to <name>.golden.yaml
+	Golden string `json:"golden,omitempty"`
+
+	dir string
+}
+
+// Result holds the result of the test case
+type Result struct {
+	Name string `json:"name"`
+	// Diff holds the unified diff between the golden file and the rendered notifications
+	Diff string `json:"diff,omitempty"`
+	// Errors holds rendering errors and invalid JSON fields of the rendered notifications
+	Errors []string `json:"errors,omitempty"`
+	// Updated indicates that the golden file has been regenerated
+	Updated bool `json:"updated,omitempty"`
+}
+
+// Passed returns true if the rendered notifications match the golden file
+func (r Result) Passed() bool {
+	return r.Diff == "" && len(r.Errors) == 0
+}
+
+// Renderer renders the notification for the destination, e.g. api.API
+type Renderer interface {
+	Render(obj map[string]interface{}, templates []string, dest services.Destination) (*services.Notification, error)
+}
+
+// LoadTestCases loads the test case from the file or test cases from *.yaml files of the directory. Golden files are
+// skipped.
+func LoadTestCases(path string) ([]TestCase, error) {
+	var paths []string
+	fileinfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fileinfo.IsDir() {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) && !strings.Contains(name, ".golden.") {
+				paths = append(paths, filepath.Join(path, name))
+			}
+		}
+	} else {
+		paths = append(paths, path)
+	}
+
+	var res []TestCase
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var testCase TestCase
+		if err := yaml.Unmarshal(data, &testCase); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal test case %s: %v", p, err)
+		}
+		testCase.Name = strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+		testCase.dir = filepath.Dir(p)
+		if testCase.Golden == "" {
+			testCase.Golden = testCase.Name + ".golden.yaml"
+		}
+		res = append(res, testCase)
+	}
+	return res, nil
+}
+
+// Run renders the test case template for each service and compares notifications with the golden file. If update is
+// true the golden file is regenerated instead.
+func Run(renderer Renderer, testCase TestCase, update bool) (Result, error) {
+	res := Result{Name: testCase.Name}
+	data, err := ioutil.ReadFile(filepath.Join(testCase.dir, testCase.Resource))
+	if err != nil {
+		return res, err
+	}
+	var resource unstructured.Unstructured
+	if err := yaml.Unmarshal(data, &resource.Object); err != nil {
+		return res, fmt.Errorf("failed to unmarshal resource %s: %v", testCase.Resource, err)
+	}
+
+	notifications := map[string]services.Notification{}
+	serviceNames := append([]string{}, testCase.Services...)
+	sort.Strings(serviceNames)
+	for _, service := range serviceNames {
+		notification, err := renderer.Render(resource.Object, []string{testCase.Template}, services.Destination{Service: service})
+		if err != nil {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", service, err))
+			continue
+		}
+		for _, err := range ValidateJSONFields(*notification) {
+			res.Errors = append(res.Errors, fmt.Sprintf("%s: %v", service, err))
+		}
+		notifications[service] = *notification
+	}
+	actual, err := yaml.Marshal(notifications)
+	if err != nil {
+		return res, err
+	}
+
+	goldenPath := filepath.Join(testCase.dir, testCase.Golden)
+	if update {
+		if len(res.Errors) == 0 {
+			res.Updated = true
+			return res, ioutil.WriteFile(goldenPath, actual, 0644)
+		}
+		return res, nil
+	}
+	expected, err := ioutil.ReadFile(goldenPath)
+	if err != nil && !os.IsNotExist(err) {
+		return res, err
+	}
+	if string(expected) != string(actual) {
+		res.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(expected)),
+			B:        difflib.SplitLines(string(actual)),
+			FromFile: testCase.Golden,
+			ToFile:   "rendered",
+			Context:  3,
+		})
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// ValidateJSONFields returns errors of the rendered notification fields that services parse as JSON or YAML. Webhook
+// bodies are validated only if they look like a JSON object or array, since webhooks might send any payload.
+func ValidateJSONFields(n services.Notification) []error {
+	fields := map[string]string{}
+	yamlFields := map[string]string{}
+	if n.Slack != nil {
+		fields["slack.attachments"] = n.Slack.Attachments
+		fields["slack.blocks"] = n.Slack.Blocks
+	}
+	if n.Mattermost != nil {
+		fields["mattermost.attachments"] = n.Mattermost.Attachments
+	}
+	if n.RocketChat != nil {
+		fields["rocketchat.attachments"] = n.RocketChat.Attachments
+	}
+	if n.Teams != nil {
+		fields["teams.sections"] = n.Teams.Sections
+		fields["teams.facts"] = n.Teams.Facts
+		fields["teams.potentialAction"] = n.Teams.PotentialAction
+	}
+	for name, webhook := range n.Webhook {
+		if body := strings.TrimSpace(webhook.Body); strings.HasPrefix(body, "{") || strings.HasPrefix(body, "[") {
+			fields[fmt.Sprintf("webhook.%s.body", name)] = body
+		}
+	}
+	if n.GoogleChat != nil {
+		yamlFields["googlechat.cards"] = n.GoogleChat.Cards
+	}
+	var errs []error
+	for _, name := range sortedKeys(fields) {
+		if value := fields[name]; value != "" && !json.Valid([]byte(value)) {
+			errs = append(errs, fmt.Errorf("%s is not a valid JSON", name))
+		}
+	}
+	for _, name := range sortedKeys(yamlFields) {
+		var parsed interface{}
+		if value := yamlFields[name]; value != "" && yaml.Unmarshal([]byte(value), &parsed) != nil {
+			errs = append(errs, fmt.Errorf("%s is not a valid YAML", name))
+		}
+	}
+	return errs
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package templatetest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/argoproj/notifications-engine/pkg/services"
+)
+
+type fakeRenderer func(obj map[string]interface{}, dest services.Destination) *services.Notification
+
+func (f fakeRenderer) Render(obj map[string]interface{}, _ []string, dest services.Destination) (*services.Notification, error) {
+	return f(obj, dest), nil
+}
+
+func writeFile(t *testing.T, path string, data string) {
+	assert.NoError(t, ioutil.WriteFile(path, []byte(data), 0644))
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templatetest")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	writeFile(t, filepath.Join(dir, "app.yaml.fixture"), "metadata: {name: guestbook}")
+	writeFile(t, filepath.Join(dir, "sync-failed.yaml"), `
+resource: app.yaml.fixture
+template: sync-failed
+services: [slack]`)
+
+	testCases, err := LoadTestCases(dir)
+	if !assert.NoError(t, err) || !assert.Len(t, testCases, 1) {
+		return
+	}
+	message := "hello"
+	renderer := fakeRenderer(func(obj map[string]interface{}, dest services.Destination) *services.Notification {
+		return &services.Notification{Message: message, Slack: &services.SlackNotification{Blocks: `[{"type": "section"}]`}}
+	})
+
+	res, err := Run(renderer, testCases[0], true)
+	assert.NoError(t, err)
+	assert.True(t, res.Updated)
+	assert.FileExists(t, filepath.Join(dir, "sync-failed.golden.yaml"))
+
+	res, err = Run(renderer, testCases[0], false)
+	assert.NoError(t, err)
+	assert.True(t, res.Passed())
+
+	message = "bye"
+	res, err = Run(renderer, testCases[0], false)
+	assert.NoError(t, err)
+	assert.False(t, res.Passed())
+	assert.Contains(t, res.Diff, "-  message: hello")
+	assert.Contains(t, res.Diff, "+  message: bye")
+}
+
+func TestValidateJSONFields(t *testing.T) {
+	errs := ValidateJSONFields(services.Notification{Slack: &services.SlackNotification{
+		Attachments: `[]`,
+		Blocks:      `[{"type": "section",}]`,
+	}})
+
+	assert.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "slack.blocks is not a valid JSON")
+}
+
+func TestValidateJSONFields_WebhookAndGoogleChat(t *testing.T) {
+	errs := ValidateJSONFields(services.Notification{
+		Webhook: services.WebhookNotifications{
+			"github":  {Body: `{"state": "success",}`},
+			"form":    {Body: `state=success`},
+			"jenkins": {Body: `[{"job": "deploy"}]`},
+		},
+		GoogleChat: &services.GoogleChatNotification{Cards: "- header: {title: [}"},
+	})
+
+	if assert.Len(t, errs, 2) {
+		assert.EqualError(t, errs[0], "webhook.github.body is not a valid JSON")
+		assert.EqualError(t, errs[1], "googlechat.cards is not a valid YAML")
+	}
+}
@@ -0,0 +1,122 @@
+package triggers
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/ast"
+	"github.com/antonmedv/expr/parser"
+	"github.com/antonmedv/expr/vm"
+)
+
+type identifierVisitor struct {
+	identifiers map[string]bool
+}
+
+func (v *identifierVisitor) Enter(_ *ast.Node) {}
+
+func (v *identifierVisitor) Exit(node *ast.Node) {
+	if n, ok := (*node).(*ast.IdentifierNode); ok {
+		v.identifiers[n.Value] = true
+	}
+}
+
+// fragmentRefs returns sorted names of the fragments directly referenced by the expression
+func fragmentRefs(code string, fragments map[string]string) ([]string, error) {
+	tree, err := parser.Parse(code)
+	if err != nil {
+		return nil, err
+	}
+	visitor := &identifierVisitor{identifiers: map[string]bool{}}
+	ast.Walk(&tree.Node, visitor)
+	var refs []string
+	for name := range visitor.identifiers {
+		if _, ok := fragments[name]; ok {
+			refs = append(refs, name)
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// compileFragments compiles the fragments and returns an error if fragments reference each other in a cycle
+func (svc *service) compileFragments(fragments map[string]string) error {
+	svc.fragmentRefs = map[string][]string{}
+	for name, code := range fragments {
+		refs, err := fragmentRefs(code, fragments)
+		if err != nil {
+			return fmt.Errorf("failed to parse fragment '%s': %v", name, err)
+		}
+		svc.fragmentRefs[name] = refs
+	}
+	for name := range fragments {
+		if _, err := svc.fragmentsOrder([]string{name}); err != nil {
+			return err
+		}
+	}
+	svc.compiledFragments = map[string]*vm.Program{}
+	for name, code := range fragments {
+		prog, err := svc.compile(fmt.Sprintf("fragment '%s'", name), code, false)
+		if err != nil {
+			return err
+		}
+		svc.compiledFragments[name] = prog
+	}
+	return nil
+}
+
+// fragmentsOrder returns the given fragments and fragments they depend on in the evaluation order
+func (svc *service) fragmentsOrder(names []string) ([]string, error) {
+	var order []string
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("fragments have a cycle: %v", append(path, name))
+		}
+		visiting[name] = true
+		for _, ref := range svc.fragmentRefs[name] {
+			if err := visit(ref, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// evalFragments evaluates the fragments required by the expression and adds results to the environment. Variables
+// take precedence over the fragments with the same name.
+func (svc *service) evalFragments(code string, vars map[string]interface{}, env map[string]interface{}, evaluated map[string]error) error {
+	for _, name := range svc.exprFragments[code] {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+		err, ok := evaluated[name]
+		if !ok {
+			var val interface{}
+			if val, err = expr.Run(svc.compiledFragments[name], env); err != nil {
+				err = fmt.Errorf("failed to evaluate fragment '%s': %v", name, err)
+			}
+			env[name] = val
+			evaluated[name] = err
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
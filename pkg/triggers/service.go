@@ -1,30 +1,128 @@
 package triggers
 
 import (
+	"bytes"
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/argoproj/notifications-engine/pkg/util/kube"
 	"github.com/argoproj/notifications-engine/pkg/util/text"
 
 	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/checker"
+	"github.com/antonmedv/expr/conf"
+	"github.com/antonmedv/expr/parser"
 	"github.com/antonmedv/expr/vm"
 	log "github.com/sirupsen/logrus"
 )
 
 // Condition holds expression and template that must be used to create notification is expression is returns true
 type Condition struct {
+	// ID is an optional stable identifier of the condition. The condition state is tracked by ID, so conditions
+	// can be reordered and edited without sending notifications again.
+	ID string `json:"id,omitempty"`
+	// Trigger is an optional name of another trigger. The condition is triggered if any condition of the referenced
+	// trigger is triggered and the when expression, if specified, returns true.
+	Trigger     string   `json:"trigger,omitempty"`
 	OncePer     string   `json:"oncePer,omitempty"`
 	When        string   `json:"when,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Send        []string `json:"send,omitempty"`
+	// SendByService holds templates keyed by the service name or type. It is populated if send is specified as a map.
+	SendByService map[string][]string `json:"-"`
+}
+
+type condition Condition
+
+type rawCondition struct {
+	condition
+	Send json.RawMessage `json:"send,omitempty"`
+}
+
+// UnmarshalJSON parses the condition, the send field might be either a list of templates or a map of template lists
+// keyed by service name or type
+func (c *Condition) UnmarshalJSON(data []byte) error {
+	var raw rawCondition
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = Condition(raw.condition)
+	send := bytes.TrimSpace(raw.Send)
+	if len(send) > 0 && send[0] == '{' {
+		return json.Unmarshal(send, &c.SendByService)
+	}
+	if len(send) > 0 {
+		return json.Unmarshal(send, &c.Send)
+	}
+	return nil
+}
+
+func (c Condition) MarshalJSON() ([]byte, error) {
+	raw := rawCondition{condition: condition(c)}
+	var send interface{}
+	if len(c.SendByService) > 0 {
+		send = c.SendByService
+	} else if len(c.Send) > 0 {
+		send = c.Send
+	}
+	if send != nil {
+		data, err := json.Marshal(send)
+		if err != nil {
+			return nil, err
+		}
+		raw.Send = data
+	}
+	return json.Marshal(raw)
+}
+
+// Mode defines how the conditions of the trigger are evaluated
+type Mode string
+
+const (
+	// ModeAllMatch triggers every condition that evaluates to true
+	ModeAllMatch Mode = "allMatch"
+	// ModeFirstMatch triggers only the first condition that evaluates to true
+	ModeFirstMatch Mode = "firstMatch"
+)
+
+// Trigger holds the trigger conditions and the evaluation mode
+type Trigger struct {
+	Mode       Mode        `json:"mode,omitempty"`
+	Conditions []Condition `json:"conditions"`
+	// SuppressInitialSync marks conditions that are true when the controller starts as notified without sending
+	SuppressInitialSync bool `json:"suppressInitialSync,omitempty"`
+	// Tests holds sample objects with the expected triggered conditions, the tests are executed by api.ValidateConfig
+	Tests []TestCase `json:"tests,omitempty"`
 }
 
 type ConditionResult struct {
-	Key       string
+	Key string
+	// LegacyKey holds the position based key of the condition that has an ID. It is used to migrate the state
+	// of the conditions that got an ID after the notification has been sent.
+	LegacyKey string
 	OncePer   string
 	Templates []string
-	Triggered bool
+	// TemplatesByService holds templates keyed by the service name or type
+	TemplatesByService map[string][]string
+	Triggered          bool
+	// Error holds the error of the when or oncePer expression evaluation
+	Error error
+}
+
+// GetTemplates returns templates that should be used to notify the destination of the given service name and type
+func (r ConditionResult) GetTemplates(serviceName string, serviceType string) []string {
+	if templates, ok := r.TemplatesByService[serviceName]; ok {
+		return templates
+	}
+	if templates, ok := r.TemplatesByService[serviceType]; ok {
+		return templates
+	}
+	return r.Templates
 }
 
 type Service interface {
@@ -36,24 +134,109 @@ type service struct {
 	compiledConditions map[string]*vm.Program
 	compiledOncePer    map[string]*vm.Program
 	triggers           map[string][]Condition
+	modes              map[string]Mode
+	funcs              map[string]interface{}
+	sampleVars         map[string]interface{}
+	strict             bool
+	fragments          map[string]string
+	fragmentRefs       map[string][]string
+	compiledFragments  map[string]*vm.Program
+	// exprFragments holds fragments required by each expression in the evaluation order
+	exprFragments map[string][]string
 }
 
-func NewService(triggers map[string][]Condition) (*service, error) {
+type Opts func(svc *service)
+
+// WithFuncs registers additional functions available in trigger expressions. Variables take precedence over
+// functions with the same name.
+func WithFuncs(funcs map[string]interface{}) Opts {
+	return func(svc *service) {
+		for name, f := range funcs {
+			svc.funcs[name] = f
+		}
+	}
+}
+
+// WithSampleVars enables the compile-time type checking of the expressions against the given sample variables.
+// The expression that references an unknown variable or function, or a when expression that does not return
+// bool is logged, or rejected in strict mode.
+func WithSampleVars(vars map[string]interface{}) Opts {
+	return func(svc *service) {
+		svc.sampleVars = vars
+	}
+}
+
+// WithStrictMode rejects expressions that fail type checking and does not trigger the conditions with failed
+// oncePer expression, instead of disabling notifications deduplication
+func WithStrictMode(strict bool) Opts {
+	return func(svc *service) {
+		svc.strict = strict
+	}
+}
+
+// WithFragments registers named expressions that can be referenced by name from any trigger expression
+// or from other fragments
+func WithFragments(fragments map[string]string) Opts {
+	return func(svc *service) {
+		svc.fragments = fragments
+	}
+}
+
+// WithModes sets the evaluation mode of the triggers. Triggers use ModeAllMatch by default.
+func WithModes(modes map[string]Mode) Opts {
+	return func(svc *service) {
+		svc.modes = modes
+	}
+}
+
+func NewService(triggers map[string][]Condition, opts ...Opts) (*service, error) {
 	svc := service{
 		compiledConditions: map[string]*vm.Program{},
 		compiledOncePer:    map[string]*vm.Program{},
 		triggers:           triggers,
+		funcs:              kube.Funcs(),
+		exprFragments:      map[string][]string{},
+	}
+	for i := range opts {
+		opts[i](&svc)
+	}
+	if err := svc.compileFragments(svc.fragments); err != nil {
+		return nil, err
+	}
+	if err := validateTriggerRefs(triggers); err != nil {
+		return nil, err
 	}
-	for _, t := range triggers {
+	for name, mode := range svc.modes {
+		if mode != "" && mode != ModeAllMatch && mode != ModeFirstMatch {
+			return nil, fmt.Errorf("trigger '%s' has unsupported mode '%s'", name, mode)
+		}
+	}
+	for name, t := range triggers {
+		ids := map[string]bool{}
 		for _, condition := range t {
-			prog, err := expr.Compile(text.Coalesce(condition.When, "false"))
+			if condition.ID != "" {
+				if strings.Contains(condition.ID, ":") {
+					return nil, fmt.Errorf("trigger '%s' has condition id '%s' with ':' character", name, condition.ID)
+				}
+				if ids[condition.ID] {
+					return nil, fmt.Errorf("trigger '%s' has more than one condition with id '%s'", name, condition.ID)
+				}
+				ids[condition.ID] = true
+			}
+			if err := svc.addExprFragments(condition.When); err != nil {
+				return nil, fmt.Errorf("invalid when condition of trigger '%s': %v", name, err)
+			}
+			prog, err := svc.compile(fmt.Sprintf("trigger '%s'", name), text.Coalesce(condition.When, "false"), true)
 			if err != nil {
 				return nil, err
 			}
 			svc.compiledConditions[condition.When] = prog
 
 			if condition.OncePer != "" {
-				prog, err := expr.Compile(condition.OncePer)
+				if err := svc.addExprFragments(condition.OncePer); err != nil {
+					return nil, fmt.Errorf("invalid oncePer condition of trigger '%s': %v", name, err)
+				}
+				prog, err := svc.compile(fmt.Sprintf("trigger '%s'", name), condition.OncePer, false)
 				if err != nil {
 					return nil, err
 				}
@@ -64,6 +247,130 @@ func NewService(triggers map[string][]Condition) (*service, error) {
 	return &svc, nil
 }
 
+// compile compiles the expression and type checks it if sample variables are available
+func (svc *service) compile(owner string, code string, expectBool bool) (*vm.Program, error) {
+	if svc.sampleVars != nil {
+		if err := svc.typeCheck(code, expectBool); err != nil {
+			if svc.strict {
+				return nil, fmt.Errorf("failed to type check expression '%s' of %s: %v", code, owner, err)
+			}
+			log.Warnf("Failed to type check expression '%s' of %s: %v", code, owner, err)
+		}
+	}
+	return expr.Compile(code)
+}
+
+var interfaceType = reflect.TypeOf(new(interface{})).Elem()
+
+// typeCheck checks the expression against sample variables, functions and fragments. The variables with nil value
+// and fragments might have any type.
+func (svc *service) typeCheck(code string, expectBool bool) error {
+	env := make(map[string]interface{}, len(svc.funcs)+len(svc.fragments)+len(svc.sampleVars))
+	for k, v := range svc.funcs {
+		env[k] = v
+	}
+	for k := range svc.fragments {
+		env[k] = nil
+	}
+	for k, v := range svc.sampleVars {
+		env[k] = v
+	}
+	config := conf.New(env)
+	for name, tag := range config.Types {
+		if tag.Type == nil {
+			tag.Type = interfaceType
+			config.Types[name] = tag
+		}
+	}
+	tree, err := parser.Parse(code)
+	if err != nil {
+		return err
+	}
+	t, err := checker.Check(tree, config)
+	if err != nil {
+		return err
+	}
+	if expectBool && t.Kind() != reflect.Bool && t.Kind() != reflect.Interface {
+		return fmt.Errorf("expected bool, but got %v", t)
+	}
+	return nil
+}
+
+// addExprFragments records fragments required by the expression
+func (svc *service) addExprFragments(code string) error {
+	if code == "" || len(svc.fragments) == 0 {
+		return nil
+	}
+	if _, ok := svc.exprFragments[code]; ok {
+		return nil
+	}
+	refs, err := fragmentRefs(code, svc.fragments)
+	if err != nil {
+		return err
+	}
+	order, err := svc.fragmentsOrder(refs)
+	if err != nil {
+		return err
+	}
+	svc.exprFragments[code] = order
+	return nil
+}
+
+// validateTriggerRefs returns an error if a condition references an unknown trigger or triggers reference each other in a cycle
+func validateTriggerRefs(triggers map[string][]Condition) error {
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		if visited[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("triggers have a cycle: %v", append(path, name))
+		}
+		visiting[name] = true
+		for _, condition := range triggers[name] {
+			if condition.Trigger == "" {
+				continue
+			}
+			if _, ok := triggers[condition.Trigger]; !ok {
+				return fmt.Errorf("trigger '%s' references unknown trigger '%s'", name, condition.Trigger)
+			}
+			if err := visit(condition.Trigger, append(path, name)); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		return nil
+	}
+	var names []string
+	for name := range triggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendMissing appends the items that are not in the list yet
+func appendMissing(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for i := range list {
+			found = found || list[i] == item
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func hash(input string) string {
 	h := sha1.New()
 	_, _ = h.Write([]byte(input))
@@ -71,36 +378,105 @@ func hash(input string) string {
 }
 
 func (svc *service) Run(triggerName string, vars map[string]interface{}) ([]ConditionResult, error) {
-	t, ok := svc.triggers[triggerName]
-	if !ok {
+	if _, ok := svc.triggers[triggerName]; !ok {
 		return nil, fmt.Errorf("trigger '%s' is not configured", triggerName)
 	}
+	env := make(map[string]interface{}, len(svc.funcs)+len(vars))
+	for k, v := range svc.funcs {
+		env[k] = v
+	}
+	for k, v := range vars {
+		env[k] = v
+	}
+	return svc.run(triggerName, vars, env, map[string]error{})
+}
+
+func (svc *service) run(triggerName string, vars map[string]interface{}, env map[string]interface{}, fragments map[string]error) ([]ConditionResult, error) {
 	var res []ConditionResult
-	for i, condition := range t {
+	for i, condition := range svc.triggers[triggerName] {
 		conditionResult := ConditionResult{
-			Templates: condition.Send,
-			Key:       fmt.Sprintf("[%d].%s", i, hash(condition.When)),
+			Templates:          condition.Send,
+			TemplatesByService: condition.SendByService,
+			Key:                fmt.Sprintf("[%d].%s", i, hash(condition.When)),
+		}
+		if condition.ID != "" {
+			conditionResult.LegacyKey = conditionResult.Key
+			conditionResult.Key = condition.ID
 		}
 
-		if prog, ok := svc.compiledConditions[condition.When]; !ok {
+		refTriggered := true
+		if condition.Trigger != "" {
+			refResults, err := svc.run(condition.Trigger, vars, env, fragments)
+			if err != nil {
+				return nil, err
+			}
+			refTriggered = false
+			for _, refResult := range refResults {
+				if refResult.Error != nil && conditionResult.Error == nil {
+					conditionResult.Error = fmt.Errorf("trigger '%s': %v", condition.Trigger, refResult.Error)
+				}
+				if refResult.Triggered {
+					refTriggered = true
+					if len(condition.Send) == 0 && len(condition.SendByService) == 0 {
+						conditionResult.Templates = appendMissing(conditionResult.Templates, refResult.Templates...)
+						for service, templates := range refResult.TemplatesByService {
+							if conditionResult.TemplatesByService == nil {
+								conditionResult.TemplatesByService = map[string][]string{}
+							}
+							conditionResult.TemplatesByService[service] = appendMissing(conditionResult.TemplatesByService[service], templates...)
+						}
+					}
+				}
+			}
+		}
+
+		if condition.Trigger != "" && condition.When == "" {
+			conditionResult.Triggered = refTriggered
+		} else if prog, ok := svc.compiledConditions[condition.When]; !ok {
 			return nil, fmt.Errorf("trigger configiration has changed after initialization")
-		} else if val, err := expr.Run(prog, vars); err == nil {
+		} else if err := svc.evalFragments(condition.When, vars, env, fragments); err != nil {
+			log.Errorf("failed to execute when condition: %+v", err)
+			conditionResult.Error = fmt.Errorf("failed to execute when condition: %v", err)
+		} else if val, err := expr.Run(prog, env); err == nil {
 			boolRes, ok := val.(bool)
-			conditionResult.Triggered = ok && boolRes
+			conditionResult.Triggered = refTriggered && ok && boolRes
+			if !ok {
+				conditionResult.Error = fmt.Errorf("when condition returned %v instead of bool", val)
+			}
 		} else {
 			log.Errorf("failed to execute when condition: %+v", err)
+			conditionResult.Error = fmt.Errorf("failed to execute when condition: %v", err)
 		}
 
 		if prog, ok := svc.compiledOncePer[condition.OncePer]; ok {
-			if val, err := expr.Run(prog, vars); err == nil {
+			err := svc.evalFragments(condition.OncePer, vars, env, fragments)
+			var val interface{}
+			if err == nil {
+				val, err = expr.Run(prog, env)
+			}
+			if err == nil {
 				conditionResult.OncePer = fmt.Sprintf("%v", val)
 			} else {
 				log.Errorf("failed to execute oncePer condition: %+v", err)
+				conditionResult.Error = fmt.Errorf("failed to execute oncePer condition: %v", err)
+				if svc.strict {
+					conditionResult.Triggered = false
+				}
 			}
 		}
 
 		res = append(res, conditionResult)
 	}
 
+	if svc.modes[triggerName] == ModeFirstMatch {
+		matched := false
+		for i := range res {
+			if matched {
+				res[i].Triggered = false
+			}
+			matched = matched || res[i].Triggered
+		}
+	}
+
 	return res, nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/ghodss/yaml"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -158,3 +159,274 @@ func TestRun_OncePer_Evaluate(t *testing.T) {
 		}}, res)
 	}
 }
+
+func TestRun_Funcs(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{
+			When:    "isProd(var1)",
+			OncePer: "revision",
+		}},
+	}, WithFuncs(map[string]interface{}{
+		"isProd": func(env string) bool {
+			return env == "prod"
+		},
+		"revision": "func-value",
+	}))
+
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("my-trigger", map[string]interface{}{"var1": "prod", "revision": "abc"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, res[0].Triggered)
+	assert.Equal(t, "abc", res[0].OncePer)
+
+	res, err = svc.Run("my-trigger", map[string]interface{}{"var1": "dev"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, res[0].Triggered)
+}
+
+func TestRun_ConditionID(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{
+			ID:   "synced",
+			When: "var1 == 'abc'",
+			Send: []string{"my-template"},
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("my-trigger", map[string]interface{}{"var1": "abc"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []ConditionResult{{
+		Key:       "synced",
+		LegacyKey: fmt.Sprintf("[0].%s", hash("var1 == 'abc'")),
+		Triggered: true,
+		Templates: []string{"my-template"},
+	}}, res)
+}
+
+func TestNewService_DuplicateConditionID(t *testing.T) {
+	_, err := NewService(map[string][]Condition{
+		"my-trigger": {{ID: "synced", When: "true"}, {ID: "synced", When: "false"}},
+	})
+	assert.EqualError(t, err, "trigger 'my-trigger' has more than one condition with id 'synced'")
+}
+
+func TestNewService_ConditionIDWithColon(t *testing.T) {
+	_, err := NewService(map[string][]Condition{
+		"my-trigger": {{ID: "sync:ed", When: "true"}},
+	})
+	assert.EqualError(t, err, "trigger 'my-trigger' has condition id 'sync:ed' with ':' character")
+}
+
+func TestRun_Error(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "var1.foo == 'abc'", OncePer: "var2.bar"}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("my-trigger", map[string]interface{}{"var1": "abc"})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.False(t, res[0].Triggered)
+		assert.Error(t, res[0].Error)
+	}
+}
+
+func TestRun_StrictMode_OncePerError(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "true", OncePer: "var1.foo.bar"}},
+	}, WithStrictMode(true))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("my-trigger", map[string]interface{}{"var1": map[string]interface{}{}})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.False(t, res[0].Triggered)
+		assert.Error(t, res[0].Error)
+	}
+}
+
+func TestNewService_TypeCheck(t *testing.T) {
+	sampleVars := map[string]interface{}{"app": map[string]interface{}{}, "context": nil}
+
+	_, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "ap.status == 'Healthy'"}},
+	}, WithSampleVars(sampleVars), WithStrictMode(true))
+	assert.Error(t, err)
+
+	_, err = NewService(map[string][]Condition{
+		"my-trigger": {{When: "isConditionTrue(app)"}},
+	}, WithSampleVars(sampleVars), WithStrictMode(true))
+	assert.Error(t, err)
+
+	_, err = NewService(map[string][]Condition{
+		"my-trigger": {{When: "len(app)"}},
+	}, WithSampleVars(sampleVars), WithStrictMode(true))
+	assert.Error(t, err)
+
+	_, err = NewService(map[string][]Condition{
+		"my-trigger": {{When: "app.status == 'Healthy' && isConditionTrue(app, 'Ready')", OncePer: "app.metadata.name"}},
+	}, WithSampleVars(sampleVars), WithStrictMode(true))
+	assert.NoError(t, err)
+
+	_, err = NewService(map[string][]Condition{
+		"my-trigger": {{When: "ap.status == 'Healthy'"}},
+	}, WithSampleVars(sampleVars))
+	assert.NoError(t, err)
+}
+
+func TestRun_Fragments(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "isProd && synced", Send: []string{"my-template"}}},
+	}, WithFragments(map[string]string{
+		"isProd": "app.env == 'prod'",
+		"synced": "isProd && app.status == 'Synced'",
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("my-trigger", map[string]interface{}{"app": map[string]interface{}{"env": "prod", "status": "Synced"}})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.True(t, res[0].Triggered)
+	}
+
+	res, err = svc.Run("my-trigger", map[string]interface{}{"app": map[string]interface{}{"env": "dev", "status": "Synced"}})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.False(t, res[0].Triggered)
+	}
+}
+
+func TestNewService_FragmentsCycle(t *testing.T) {
+	_, err := NewService(map[string][]Condition{}, WithFragments(map[string]string{
+		"a": "b && true",
+		"b": "a || false",
+	}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "fragments have a cycle")
+}
+
+func TestRun_TriggerReference(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"on-degraded": {
+			{When: "app.health == 'Degraded'", Send: []string{"app-degraded"}},
+			{When: "app.health == 'Missing'", Send: []string{"app-missing"}},
+		},
+		"on-prod-degraded": {{Trigger: "on-degraded", When: "app.env == 'prod'"}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("on-prod-degraded", map[string]interface{}{"app": map[string]interface{}{"env": "prod", "health": "Degraded"}})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.True(t, res[0].Triggered)
+		assert.Equal(t, []string{"app-degraded"}, res[0].Templates)
+	}
+
+	res, err = svc.Run("on-prod-degraded", map[string]interface{}{"app": map[string]interface{}{"env": "dev", "health": "Degraded"}})
+	if assert.NoError(t, err) && assert.Len(t, res, 1) {
+		assert.False(t, res[0].Triggered)
+	}
+}
+
+func TestNewService_TriggerReferenceErrors(t *testing.T) {
+	_, err := NewService(map[string][]Condition{
+		"a": {{Trigger: "b"}},
+		"b": {{Trigger: "a"}},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "triggers have a cycle")
+	}
+
+	_, err = NewService(map[string][]Condition{
+		"a": {{Trigger: "unknown"}},
+	})
+	assert.EqualError(t, err, "trigger 'a' references unknown trigger 'unknown'")
+}
+
+func TestNewService_TypeCheckFragments(t *testing.T) {
+	_, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "isProd"}},
+	}, WithFragments(map[string]string{"isProd": "app.env == 'prod'"}),
+		WithSampleVars(map[string]interface{}{"app": map[string]interface{}{}}), WithStrictMode(true))
+	assert.NoError(t, err)
+}
+
+func TestRun_FirstMatch(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {
+			{When: "errors > 10", Send: []string{"critical"}},
+			{When: "errors > 5", Send: []string{"warning"}},
+			{When: "errors > 0", Send: []string{"info"}},
+		},
+	}, WithModes(map[string]Mode{"my-trigger": ModeFirstMatch}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := svc.Run("my-trigger", map[string]interface{}{"errors": 7})
+	if assert.NoError(t, err) && assert.Len(t, res, 3) {
+		assert.False(t, res[0].Triggered)
+		assert.True(t, res[1].Triggered)
+		assert.False(t, res[2].Triggered)
+	}
+}
+
+func TestNewService_UnsupportedMode(t *testing.T) {
+	_, err := NewService(map[string][]Condition{"my-trigger": {{When: "true"}}}, WithModes(map[string]Mode{"my-trigger": "any"}))
+	assert.EqualError(t, err, "trigger 'my-trigger' has unsupported mode 'any'")
+}
+
+func TestCondition_UnmarshalSendByService(t *testing.T) {
+	var conditions []Condition
+	err := yaml.Unmarshal([]byte(`
+- when: "true"
+  send: [app-synced]
+- when: "true"
+  send:
+    slack: [app-synced-slack]
+    github: [app-synced-github]
+`), &conditions)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []Condition{
+		{When: "true", Send: []string{"app-synced"}},
+		{When: "true", SendByService: map[string][]string{"slack": {"app-synced-slack"}, "github": {"app-synced-github"}}},
+	}, conditions)
+
+	data, err := yaml.Marshal(conditions)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var parsed []Condition
+	if assert.NoError(t, yaml.Unmarshal(data, &parsed)) {
+		assert.Equal(t, conditions, parsed)
+	}
+}
+
+func TestConditionResult_GetTemplates(t *testing.T) {
+	res := ConditionResult{
+		Templates:          []string{"default"},
+		TemplatesByService: map[string][]string{"slack": {"slack-template"}, "my-github": {"github-template"}},
+	}
+
+	assert.Equal(t, []string{"github-template"}, res.GetTemplates("my-github", "github"))
+	assert.Equal(t, []string{"slack-template"}, res.GetTemplates("team-slack", "slack"))
+	assert.Equal(t, []string{"default"}, res.GetTemplates("email", "email"))
+}
@@ -0,0 +1,60 @@
+package triggers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// TestCase holds the sample object and the conditions of the trigger that are expected to be triggered for it
+type TestCase struct {
+	Name string `json:"name"`
+	// Object holds the sample object the trigger is evaluated against
+	Object map[string]interface{} `json:"object"`
+	// Triggered holds ids of the expected triggered conditions, conditions without id are referenced by the position,
+	// e.g. [0]
+	Triggered []string `json:"triggered"`
+}
+
+// Verify returns an error if the triggered conditions do not match the expected ones or condition evaluation failed
+func (tc TestCase) Verify(results []ConditionResult) error {
+	var errs []string
+	for _, r := range results {
+		if r.Error != nil {
+			errs = append(errs, fmt.Sprintf("condition %s: %v", ConditionName(r), r.Error))
+		}
+	}
+	expected := append([]string{}, tc.Triggered...)
+	sort.Strings(expected)
+	actual := TriggeredConditions(results)
+	if len(expected) != 0 || len(actual) != 0 {
+		if !reflect.DeepEqual(expected, actual) {
+			errs = append(errs, fmt.Sprintf("expected triggered conditions %v, got %v", expected, actual))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("test case '%s' failed: %s", tc.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ConditionName returns the condition id or the position of the condition without id, e.g. [0]
+func ConditionName(r ConditionResult) string {
+	if r.LegacyKey != "" {
+		return r.Key
+	}
+	return strings.SplitN(r.Key, ".", 2)[0]
+}
+
+// TriggeredConditions returns sorted names of the triggered conditions
+func TriggeredConditions(results []ConditionResult) []string {
+	var res []string
+	for _, r := range results {
+		if r.Triggered {
+			res = append(res, ConditionName(r))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,55 @@
+package triggers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestCase_Verify(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{
+			When: "var1 == 'abc'",
+			Send: []string{"my-template"},
+		}, {
+			ID:   "degraded",
+			When: "var2 == 'Degraded'",
+			Send: []string{"my-template"},
+		}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	results, err := svc.Run("my-trigger", map[string]interface{}{"var1": "abc", "var2": "Degraded"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"[0]", "degraded"}, TriggeredConditions(results))
+	assert.NoError(t, TestCase{Name: "both", Triggered: []string{"degraded", "[0]"}}.Verify(results))
+	assert.EqualError(t, TestCase{Name: "first", Triggered: []string{"[0]"}}.Verify(results),
+		"test case 'first' failed: expected triggered conditions [[0]], got [[0] degraded]")
+
+	results, err = svc.Run("my-trigger", map[string]interface{}{"var1": "def", "var2": "Healthy"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, TestCase{Name: "none"}.Verify(results))
+}
+
+func TestTestCase_VerifyConditionError(t *testing.T) {
+	svc, err := NewService(map[string][]Condition{
+		"my-trigger": {{When: "var1.foo == 'abc'"}},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	results, err := svc.Run("my-trigger", map[string]interface{}{"var1": nil})
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = TestCase{Name: "nil"}.Verify(results)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "condition [0]: failed to execute when condition")
+}
@@ -0,0 +1,190 @@
+package kube
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Funcs returns Kubernetes aware helper functions available in notification templates and trigger expressions
+func Funcs() map[string]interface{} {
+	return map[string]interface{}{
+		"condition":        Condition,
+		"conditionStatus":  ConditionStatus,
+		"isConditionTrue":  IsConditionTrue,
+		"age":              Age,
+		"since":            Since,
+		"humanizeDuration": HumanizeDuration,
+		"label":            Label,
+		"annotation":       Annotation,
+		"quantity":         Quantity,
+		"field":            Field,
+	}
+}
+
+// Condition returns the condition with the given type from the .status.conditions list or nil if there is no such condition
+func Condition(obj interface{}, conditionType string) map[string]interface{} {
+	conditions, ok := Field(obj, "status.conditions").([]interface{})
+	if !ok {
+		return nil
+	}
+	for i := range conditions {
+		if condition, ok := conditions[i].(map[string]interface{}); ok && condition["type"] == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+// ConditionStatus returns status of the condition with the given type or empty string if there is no such condition
+func ConditionStatus(obj interface{}, conditionType string) string {
+	if status, ok := Condition(obj, conditionType)["status"].(string); ok {
+		return status
+	}
+	return ""
+}
+
+// IsConditionTrue returns true if the condition with the given type has "True" status
+func IsConditionTrue(obj interface{}, conditionType string) bool {
+	return ConditionStatus(obj, conditionType) == "True"
+}
+
+// Age returns time elapsed since the object creation timestamp
+func Age(obj interface{}) time.Duration {
+	return Since(Field(obj, "metadata.creationTimestamp"))
+}
+
+// Since returns time elapsed since the given RFC3339 timestamp or time. Returns zero duration if timestamp cannot be parsed.
+func Since(timestamp interface{}) time.Duration {
+	switch t := timestamp.(type) {
+	case time.Time:
+		return time.Since(t)
+	case *time.Time:
+		if t != nil {
+			return time.Since(*t)
+		}
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, t); err == nil {
+			return time.Since(parsed)
+		}
+	}
+	return 0
+}
+
+// HumanizeDuration formats the duration in a short human-readable form, e.g. 3d4h, 5m12s
+func HumanizeDuration(d time.Duration) string {
+	if d < 0 {
+		return "-" + HumanizeDuration(-d)
+	}
+	seconds := int64(d.Round(time.Second) / time.Second)
+	days, seconds := seconds/86400, seconds%86400
+	hours, seconds := seconds/3600, seconds%3600
+	minutes, seconds := seconds/60, seconds%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// Label returns value of the object label or empty string if label is missing
+func Label(obj interface{}, key string) string {
+	return stringValue(Field(obj, "metadata.labels"), key)
+}
+
+// Annotation returns value of the object annotation or empty string if annotation is missing
+func Annotation(obj interface{}, key string) string {
+	return stringValue(Field(obj, "metadata.annotations"), key)
+}
+
+func stringValue(m interface{}, key string) string {
+	switch m := m.(type) {
+	case map[string]interface{}:
+		val, _ := m[key].(string)
+		return val
+	case map[string]string:
+		return m[key]
+	}
+	return ""
+}
+
+// Quantity parses the resource quantity, e.g. 500Mi or 0.5, and returns its approximate value
+func Quantity(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case string:
+		q, err := resource.ParseQuantity(v)
+		if err != nil {
+			return 0, err
+		}
+		return q.AsApproximateFloat64(), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("cannot parse quantity from %v", val)
+	}
+}
+
+// Field returns the value located at the given path, e.g. "status.conditions[0].type" or
+// "metadata.labels['app.kubernetes.io/name']". Returns nil if any element of the path is missing.
+func Field(obj interface{}, path string) interface{} {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "{"), "}")
+	current := obj
+	for _, segment := range parsePath(path) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current = node[segment]
+		case map[string]string:
+			current = node[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			current = node[index]
+		default:
+			return nil
+		}
+		if current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// parsePath splits the path into keys and indexes
+func parsePath(path string) []string {
+	var segments []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return append(segments, path[1:])
+			}
+			segments = append(segments, strings.Trim(path[1:end], `'"`))
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+	return segments
+}
@@ -0,0 +1,98 @@
+package kube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antonmedv/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+var obj = map[string]interface{}{
+	"metadata": map[string]interface{}{
+		"name":              "guestbook",
+		"creationTimestamp": time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+		"labels": map[string]interface{}{
+			"app.kubernetes.io/name": "guestbook",
+		},
+	},
+	"status": map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+			map[string]interface{}{"type": "Degraded", "status": "False", "message": "all good"},
+		},
+	},
+}
+
+func TestCondition(t *testing.T) {
+	assert.Equal(t, map[string]interface{}{"type": "Degraded", "status": "False", "message": "all good"}, Condition(obj, "Degraded"))
+	assert.Nil(t, Condition(obj, "Missing"))
+	assert.Nil(t, Condition(nil, "Ready"))
+	assert.Equal(t, "True", ConditionStatus(obj, "Ready"))
+	assert.Equal(t, "", ConditionStatus(obj, "Missing"))
+	assert.True(t, IsConditionTrue(obj, "Ready"))
+	assert.False(t, IsConditionTrue(obj, "Degraded"))
+}
+
+func TestAge(t *testing.T) {
+	assert.InDelta(t, 2, Age(obj).Hours(), 0.1)
+	assert.Equal(t, time.Duration(0), Age(map[string]interface{}{}))
+	assert.Equal(t, time.Duration(0), Since("not a timestamp"))
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	assert.Equal(t, "3d4h", HumanizeDuration(76*time.Hour+5*time.Minute))
+	assert.Equal(t, "2h5m", HumanizeDuration(2*time.Hour+5*time.Minute))
+	assert.Equal(t, "5m12s", HumanizeDuration(5*time.Minute+12*time.Second))
+	assert.Equal(t, "7s", HumanizeDuration(7*time.Second))
+	assert.Equal(t, "-7s", HumanizeDuration(-7*time.Second))
+}
+
+func TestLabelAndAnnotation(t *testing.T) {
+	assert.Equal(t, "guestbook", Label(obj, "app.kubernetes.io/name"))
+	assert.Equal(t, "", Label(obj, "missing"))
+	assert.Equal(t, "", Annotation(obj, "missing"))
+	assert.Equal(t, "", Annotation(nil, "missing"))
+}
+
+func TestQuantity(t *testing.T) {
+	val, err := Quantity("500Mi")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(500*1024*1024), val)
+
+	val, err = Quantity("250m")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, val)
+
+	_, err = Quantity("abc")
+	assert.Error(t, err)
+}
+
+func TestField(t *testing.T) {
+	assert.Equal(t, "Degraded", Field(obj, "status.conditions[1].type"))
+	assert.Equal(t, "guestbook", Field(obj, "{.metadata.labels['app.kubernetes.io/name']}"))
+	assert.Nil(t, Field(obj, "status.conditions[5].type"))
+	assert.Nil(t, Field(obj, "status.operationState.phase"))
+	assert.Nil(t, Field(nil, "status"))
+}
+
+func TestFuncs_Expr(t *testing.T) {
+	env := Funcs()
+	env["app"] = obj
+
+	for _, expression := range []string{
+		`isConditionTrue(app, "Ready")`,
+		`condition(app, "Degraded").message == "all good"`,
+		`age(app).Hours() > 1`,
+		`label(app, "app.kubernetes.io/name") == "guestbook"`,
+		`field(app, "status.operationState.phase") == nil`,
+	} {
+		prog, err := expr.Compile(expression)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		res, err := expr.Run(prog, env)
+		assert.NoError(t, err)
+		assert.Equal(t, true, res, expression)
+	}
+}
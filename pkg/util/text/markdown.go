@@ -0,0 +1,223 @@
+package text
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdBold        = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalic      = regexp.MustCompile(`\*([^*\s][^*]*?)\*|\b_([^_\s][^_]*?)_\b`)
+	mdStrike      = regexp.MustCompile(`~~(.+?)~~`)
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdListItem    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdOrderedItem = regexp.MustCompile(`^(\s*)\d+[.)]\s+(.*)$`)
+	mdCodeSpan    = regexp.MustCompile("`[^`]+`")
+)
+
+// boldPlaceholder temporary replaces bold markers so that they are not converted by italic conversion
+const boldPlaceholder = "\x00"
+
+// SlackEscape escapes characters that have special meaning in Slack mrkdwn
+func SlackEscape(val string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(val)
+}
+
+// convertInline applies the conversion to the parts of the line that are not code spans
+func convertInline(line string, convert func(string) string, convertCode func(string) string) string {
+	var res strings.Builder
+	last := 0
+	for _, loc := range mdCodeSpan.FindAllStringIndex(line, -1) {
+		res.WriteString(convert(line[last:loc[0]]))
+		res.WriteString(convertCode(line[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	res.WriteString(convert(line[last:]))
+	return res.String()
+}
+
+func firstGroup(groups []string) string {
+	for _, g := range groups[1:] {
+		if g != "" {
+			return g
+		}
+	}
+	return ""
+}
+
+func slackInline(val string) string {
+	val = SlackEscape(val)
+	val = mdLink.ReplaceAllString(val, "<$2|$1>")
+	val = mdBold.ReplaceAllStringFunc(val, func(m string) string {
+		return boldPlaceholder + firstGroup(mdBold.FindStringSubmatch(m)) + boldPlaceholder
+	})
+	val = mdItalic.ReplaceAllStringFunc(val, func(m string) string {
+		return "_" + firstGroup(mdItalic.FindStringSubmatch(m)) + "_"
+	})
+	val = mdStrike.ReplaceAllString(val, "~$1~")
+	return strings.ReplaceAll(val, boldPlaceholder, "*")
+}
+
+// MarkdownToSlack converts Markdown to Slack mrkdwn format
+func MarkdownToSlack(val string) string {
+	lines := strings.Split(val, "\n")
+	inCode := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			lines[i] = SlackEscape(line)
+			continue
+		}
+		switch {
+		case inCode:
+			lines[i] = SlackEscape(line)
+		case mdHeading.MatchString(line):
+			lines[i] = "*" + convertInline(mdHeading.FindStringSubmatch(line)[2], slackInline, SlackEscape) + "*"
+		case mdListItem.MatchString(line):
+			groups := mdListItem.FindStringSubmatch(line)
+			lines[i] = groups[1] + "• " + convertInline(groups[2], slackInline, SlackEscape)
+		default:
+			lines[i] = convertInline(line, slackInline, SlackEscape)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// MarkdownToTeams converts Markdown to the Markdown subset supported by Microsoft Teams message cards
+func MarkdownToTeams(val string) string {
+	lines := strings.Split(val, "\n")
+	inCode := false
+	var res []string
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			res = append(res, line)
+			continue
+		}
+		switch {
+		case inCode:
+			res = append(res, line)
+		case mdHeading.MatchString(line):
+			res = append(res, "**"+mdHeading.FindStringSubmatch(line)[2]+"**", "")
+		case strings.TrimSpace(line) == "":
+			continue
+		default:
+			// teams ignores single line breaks so every line becomes a separate paragraph
+			res = append(res, line, "")
+		}
+	}
+	return strings.TrimRight(strings.Join(res, "\n"), "\n")
+}
+
+func htmlInline(val string) string {
+	val = html.EscapeString(val)
+	val = mdLink.ReplaceAllStringFunc(val, func(m string) string {
+		groups := mdLink.FindStringSubmatch(m)
+		href := html.UnescapeString(groups[2])
+		if !isSafeLink(href) {
+			return groups[1]
+		}
+		return `<a href="` + html.EscapeString(href) + `">` + groups[1] + `</a>`
+	})
+	val = mdBold.ReplaceAllStringFunc(val, func(m string) string {
+		return "<strong>" + firstGroup(mdBold.FindStringSubmatch(m)) + "</strong>"
+	})
+	val = mdItalic.ReplaceAllStringFunc(val, func(m string) string {
+		return "<em>" + firstGroup(mdItalic.FindStringSubmatch(m)) + "</em>"
+	})
+	return mdStrike.ReplaceAllString(val, "<del>$1</del>")
+}
+
+// isSafeLink returns true if the link uses the http, https or mailto scheme
+func isSafeLink(val string) bool {
+	u, err := url.Parse(val)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func htmlCode(val string) string {
+	return "<code>" + html.EscapeString(strings.Trim(val, "`")) + "</code>"
+}
+
+// MarkdownToHTML converts basic Markdown (headings, emphasis, links, lists, code) to HTML
+func MarkdownToHTML(val string) string {
+	var res strings.Builder
+	var paragraph []string
+	list := ""
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			res.WriteString("<p>" + strings.Join(paragraph, "\n") + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			res.WriteString(fmt.Sprintf("</%s>\n", list))
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			res.WriteString(fmt.Sprintf("<%s>\n", tag))
+			list = tag
+		}
+	}
+
+	for _, line := range strings.Split(val, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			flushParagraph()
+			closeList()
+			if inCode {
+				res.WriteString("</code></pre>\n")
+			} else {
+				res.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			res.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+			closeList()
+		case mdHeading.MatchString(line):
+			flushParagraph()
+			closeList()
+			groups := mdHeading.FindStringSubmatch(line)
+			res.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", len(groups[1]), convertInline(groups[2], htmlInline, htmlCode), len(groups[1])))
+		case mdListItem.MatchString(line):
+			flushParagraph()
+			openList("ul")
+			res.WriteString("<li>" + convertInline(mdListItem.FindStringSubmatch(line)[2], htmlInline, htmlCode) + "</li>\n")
+		case mdOrderedItem.MatchString(line):
+			flushParagraph()
+			openList("ol")
+			res.WriteString("<li>" + convertInline(mdOrderedItem.FindStringSubmatch(line)[2], htmlInline, htmlCode) + "</li>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, convertInline(strings.TrimSpace(line), htmlInline, htmlCode))
+		}
+	}
+	flushParagraph()
+	closeList()
+	if inCode {
+		res.WriteString("</code></pre>\n")
+	}
+	return strings.TrimSuffix(res.String(), "\n")
+}
@@ -0,0 +1,61 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const markdown = "# Sync **failed**\n" +
+	"Application *guestbook* has ~~succeeded~~ failed, see [details](https://example.com/app?a=1&b=2).\n" +
+	"\n" +
+	"- commit `a < b`\n" +
+	"- author __john__\n" +
+	"```\n" +
+	"if a < b {}\n" +
+	"```"
+
+func TestMarkdownToSlack(t *testing.T) {
+	assert.Equal(t, "*Sync *failed**\n"+
+		"Application _guestbook_ has ~succeeded~ failed, see <https://example.com/app?a=1&amp;b=2|details>.\n"+
+		"\n"+
+		"• commit `a &lt; b`\n"+
+		"• author *john*\n"+
+		"```\n"+
+		"if a &lt; b {}\n"+
+		"```", MarkdownToSlack(markdown))
+}
+
+func TestMarkdownToTeams(t *testing.T) {
+	assert.Equal(t, "**Sync **failed****\n\n"+
+		"Application *guestbook* has ~~succeeded~~ failed, see [details](https://example.com/app?a=1&b=2).\n\n"+
+		"- commit `a < b`\n\n"+
+		"- author __john__\n\n"+
+		"```\n"+
+		"if a < b {}\n"+
+		"```", MarkdownToTeams(markdown))
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	assert.Equal(t, "<h1>Sync <strong>failed</strong></h1>\n"+
+		"<p>Application <em>guestbook</em> has <del>succeeded</del> failed, see <a href=\"https://example.com/app?a=1&amp;b=2\">details</a>.</p>\n"+
+		"<ul>\n"+
+		"<li>commit <code>a &lt; b</code></li>\n"+
+		"<li>author <strong>john</strong></li>\n"+
+		"</ul>\n"+
+		"<pre><code>if a &lt; b {}\n"+
+		"</code></pre>", MarkdownToHTML(markdown))
+}
+
+func TestMarkdownToHTML_UnsafeLinks(t *testing.T) {
+	assert.Equal(t, "<p>click, <a href=\"mailto:ops@example.com\">mail</a>, <a href=\"https://example.com/&#34;onclick=&#34;x\">quoted</a></p>",
+		MarkdownToHTML(`[click](javascript:alert), [mail](mailto:ops@example.com), [quoted](https://example.com/"onclick="x)`))
+}
+
+func TestMarkdownToHTML_OrderedList(t *testing.T) {
+	assert.Equal(t, "<ol>\n<li>first</li>\n<li>second</li>\n</ol>\n<p>done</p>", MarkdownToHTML("1. first\n2. second\n\ndone"))
+}
+
+func TestSlackEscape(t *testing.T) {
+	assert.Equal(t, "a &lt;b&gt; &amp; c", SlackEscape("a <b> & c"))
+}
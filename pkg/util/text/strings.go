@@ -1,6 +1,9 @@
 package text
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 func Coalesce(first string, other ...string) string {
 	res := first
@@ -22,3 +25,14 @@ func SplitRemoveEmpty(in string, separator string) []string {
 	}
 	return res
 }
+
+// Truncate shortens the string to at most n characters, replacing the tail with "..." if the string is too long
+func Truncate(val string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(val) <= n {
+		return val
+	}
+	if n <= 3 {
+		return string([]rune(val)[:n])
+	}
+	return string([]rune(val)[:n-3]) + "..."
+}
@@ -0,0 +1,14 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "hello", Truncate("hello", 5))
+	assert.Equal(t, "he...", Truncate("hello world", 5))
+	assert.Equal(t, "прив...", Truncate("привет мир", 7))
+	assert.Equal(t, "hello", Truncate("hello", 0))
+}